@@ -0,0 +1,64 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// FrameSpan 一个帧在数据中的位置，见 Analyze
+type FrameSpan struct {
+	Offset    int  // 帧（包括 Magic）在数据中的起始位置
+	HeaderLen int  // 头部长度，包括 Magic、LengthFieldOffset 之前的字节和头部校验和
+	BodyLen   int  // 长度字段描述的 body 长度（已应用 LengthAdjustment 等修正，包含校验和），不含尾部
+	Truncated bool // 数据在这个帧中间结束；头部也不完整时 HeaderLen 和 BodyLen 为 0
+}
+
+// End 返回帧结束的位置（不包含），即 Offset + HeaderLen + BodyLen + TrailerLength
+func (s FrameSpan) End(hc *HeaderConfig) int {
+	return s.Offset + s.HeaderLen + s.BodyLen + hc.TrailerLength
+}
+
+// Analyze 按 hc 解析已经完整读入内存的数据（例如抓包文件），返回每个帧的位置，用于协议分析和按帧边界高亮的十六进制查看器
+// - 使用与 ReadFrame 相同的头部解析和长度检查，完整的帧还会检查校验和，但不解码 body，也不触发 HeaderConfig 中的回调
+// - 数据在帧中间结束时，最后一个 FrameSpan 的 Truncated 为 true
+// - 遇到错误时停止，返回出错之前的帧和带有出错位置的错误（可以通过 errors.Is 判断原来的错误）
+// - 忽略 Resync、DropOversized 和 AutoByteOrder（按 ByteOrder 解析）：跳过数据会掩盖 Analyze 需要报告的出错位置
+func Analyze(data []byte, hc *HeaderConfig) ([]FrameSpan, error) {
+	if hc == nil {
+		return nil, errors.New("nil HeaderConfig")
+	}
+	if err := hc.Validate(); err != nil {
+		return nil, err
+	}
+
+	var spans []FrameSpan
+	for offset := 0; offset < len(data); {
+		buf := data[offset:]
+		bodyLen, headerLen, ok, err := hc.parseHeader(buf, hc.ByteOrder, hc.MaxFrameLength)
+		if err != nil {
+			return spans, fmt.Errorf("frame at offset %d: %w", offset, err)
+		}
+		if !ok {
+			return append(spans, FrameSpan{Offset: offset, Truncated: true}), nil
+		}
+
+		span := FrameSpan{Offset: offset, HeaderLen: headerLen, BodyLen: bodyLen}
+		trailerStart := headerLen + bodyLen
+		if trailerStart < headerLen || trailerStart > math.MaxInt-hc.TrailerLength {
+			return spans, fmt.Errorf("frame at offset %d: %w: header %d + body %d", offset, ErrLengthOverflow, headerLen, bodyLen)
+		}
+		total := trailerStart + hc.TrailerLength
+		if len(buf) < total {
+			span.Truncated = true
+			return append(spans, span), nil
+		}
+		if _, _, err := hc.splitFrame(buf[:total], headerLen, trailerStart); err != nil {
+			return spans, fmt.Errorf("frame at offset %d: %w", offset, err)
+		}
+
+		spans = append(spans, span)
+		offset += total
+	}
+	return spans, nil
+}
@@ -0,0 +1,51 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestAnalyze 测试报告每个帧的位置和末尾被截断的帧
+func TestAnalyze(t *testing.T) {
+	config := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, Magic: []byte{0xAB}, TrailerLength: 1}
+	data := []byte{
+		0xAB, 0x00, 0x02, 'h', 'i', 0x0D,
+		0xAB, 0x00, 0x00, 0x0D,
+		0xAB, 0x00, 0x05, 'a', 'b',
+	}
+
+	spans, err := Analyze(data, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FrameSpan{
+		{Offset: 0, HeaderLen: 3, BodyLen: 2},
+		{Offset: 6, HeaderLen: 3, BodyLen: 0},
+		{Offset: 10, HeaderLen: 3, BodyLen: 5, Truncated: true},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("期望 %v，实际: %v", want, spans)
+	}
+	for i := range want {
+		if spans[i] != want[i] {
+			t.Errorf("第 %d 个帧期望 %+v，实际: %+v", i, want[i], spans[i])
+		}
+	}
+	if end := spans[0].End(config); end != 6 {
+		t.Errorf("第一个帧应在 6 结束，实际: %d", end)
+	}
+
+	// 头部也不完整
+	spans, err = Analyze(data[:7], config)
+	if err != nil || len(spans) != 2 || spans[1] != (FrameSpan{Offset: 6, Truncated: true}) {
+		t.Errorf("期望第二个帧只有半个头部，实际: %+v, %v", spans, err)
+	}
+
+	// 出错时返回之前的帧和出错的位置
+	bad := append(append([]byte{}, data[:6]...), 0xCD, 0x00)
+	spans, err = Analyze(bad, config)
+	if !errors.Is(err, ErrBadMagic) || len(spans) != 1 {
+		t.Errorf("期望在第二个帧返回 ErrBadMagic，实际: %v, %v", spans, err)
+	}
+}
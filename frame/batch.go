@@ -0,0 +1,110 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrBatchTooLarge 批次头部中的帧数超过 BatchReader.MaxCount
+var ErrBatchTooLarge = errors.New("batch too large")
+
+// BatchReader 解析 [帧数][子帧 1][子帧 2]... 形式的批次：先读取一个定长的帧数字段，
+// 再按 HeaderConfig 读取恰好这么多个子帧，整批一起返回
+type BatchReader struct {
+	// MaxCount 一个批次最多包含的帧数，超过时返回 ErrBatchTooLarge，0 表示不限制。
+	// 帧数字段来自对端，不受信任时应该设置，子帧本身仍然受 MaxFrameLength 限制
+	MaxCount int
+
+	frame     *Frame
+	count     FieldSpec // 帧数字段
+	remaining int       // 当前批次还没有读到的子帧个数
+	inBatch   bool      // 是否已经读出当前批次的帧数字段
+	batch     [][]byte  // 当前批次已经读到的子帧
+	err       error     // 之前出现的错误，批次中间出错后数据流的位置无法确定，之后一直返回这个错误
+}
+
+// NewBatchReader 创建一个 BatchReader，帧数字段占 countLen 字节（1、2、4 或 8），与子帧的头部使用相同的字节序，
+// 子帧按 hc 解码。hc 不合法时返回 Validate 的错误
+func NewBatchReader(hc *HeaderConfig, countLen int) (*BatchReader, error) {
+	f, err := NewFrame(hc)
+	if err != nil {
+		return nil, err
+	}
+	count := FieldSpec{Name: "count", Offset: 0, Width: countLen}
+	if err := hc.validateField(count); err != nil {
+		return nil, err
+	}
+	return &BatchReader{frame: f, count: count}, nil
+}
+
+// ReadBatch 输入一次读到的数据，返回一个完整的批次
+// - 帧数字段或者子帧还没有全部到达时返回 (nil, nil)，等待下次补充；帧数字段和子帧都可以分多次到达
+// - 如果缓冲区中有多个批次，调用方需要多次调用 ReadBatch（raw 传 nil）才能依次取出
+// - 帧数为 0 的批次返回非 nil 的空切片
+// - 配置了 ZeroCopy 时子帧仍然会被拷贝，因为整批返回之前内部缓冲区可能已经被后续输入覆盖
+// - 子帧解码出错（或者帧数超过 MaxCount）时返回这个错误，批次中间出错后无法找到下一个批次的开头，之后的调用都返回同一个错误
+func (b *BatchReader) ReadBatch(raw []byte) ([][]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	f := b.frame
+	f.lock.Lock()
+	batch, frames, err := b.readBatch(raw)
+	f.lock.Unlock()
+
+	for _, body := range frames {
+		f.notify(body, nil)
+	}
+	if err != nil {
+		b.err = err
+		f.notify(nil, err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// readBatch 是 ReadBatch 的实现，frames 为这次调用新读到的子帧，用于在锁外触发回调，调用方需持有 f.lock
+func (b *BatchReader) readBatch(raw []byte) (batch, frames [][]byte, err error) {
+	f := b.frame
+	if !b.inBatch {
+		if err := f.appendInput(raw); err != nil {
+			return nil, nil, err
+		}
+		raw = nil
+		if len(f.buf) < b.count.Width {
+			return nil, nil, nil
+		}
+		count := b.count.value(f.buf, f.byteOrder())
+		if count > math.MaxInt || (b.MaxCount > 0 && count > uint64(b.MaxCount)) {
+			return nil, nil, fmt.Errorf("%w: %d frames", ErrBatchTooLarge, count)
+		}
+		f.buf = f.buf[b.count.Width:]
+		f.consumed += uint64(b.count.Width)
+		b.remaining, b.inBatch = int(count), true
+		// 帧数不可信，不按它预分配
+		b.batch = make([][]byte, 0, min(b.remaining, 64))
+	}
+
+	for b.remaining > 0 {
+		body, err := f.readFrame(raw)
+		raw = nil
+		if err != nil {
+			return nil, frames, err
+		}
+		if body == nil {
+			return nil, frames, nil
+		}
+		if f.Hc.ZeroCopy {
+			// ZeroCopy 的 body 引用内部缓冲区，读后面的子帧时缓冲区可能被覆盖，整批返回前必须拷贝
+			body = append([]byte{}, body...)
+		}
+		frames = append(frames, body)
+		b.batch = append(b.batch, body)
+		b.remaining--
+	}
+
+	batch, b.batch, b.inBatch = b.batch, nil, false
+	return batch, frames, nil
+}
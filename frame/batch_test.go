@@ -0,0 +1,100 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestBatchReader 测试帧数字段和子帧逐字节到达时整批返回
+func TestBatchReader(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		MaxFrameLength:    16,
+		ZeroCopy:          true,
+	}
+	b, err := NewBatchReader(config, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := func(body string) []byte {
+		return append([]byte{0, byte(len(body))}, body...)
+	}
+
+	// 三个子帧的批次（含一个空帧），后面紧跟一个空批次和下一个批次的帧数字段
+	var data []byte
+	data = append(data, 0, 0, 0, 3)
+	data = append(data, frame("ab")...)
+	data = append(data, frame("")...)
+	data = append(data, frame("cde")...)
+	data = append(data, 0, 0, 0, 0)
+	data = append(data, 0, 0, 0, 1)
+
+	var batches [][][]byte
+	for i := range data {
+		batch, err := b.ReadBatch(data[i : i+1])
+		if err != nil {
+			t.Fatalf("第 %d 字节: %v", i, err)
+		}
+		if batch != nil {
+			batches = append(batches, batch)
+		}
+		for batch != nil {
+			if batch, err = b.ReadBatch(nil); err != nil {
+				t.Fatal(err)
+			}
+			if batch != nil {
+				batches = append(batches, batch)
+			}
+		}
+	}
+	if len(batches) != 2 {
+		t.Fatalf("期望 2 个批次，实际: %d", len(batches))
+	}
+	want := []string{"ab", "", "cde"}
+	if len(batches[0]) != len(want) {
+		t.Fatalf("期望 %d 个子帧，实际: %d", len(want), len(batches[0]))
+	}
+	for i, body := range batches[0] {
+		if body == nil || string(body) != want[i] {
+			t.Errorf("子帧 %d: 期望 %q，实际: %q", i, want[i], body)
+		}
+	}
+	if batches[1] == nil || len(batches[1]) != 0 {
+		t.Errorf("期望空批次返回非 nil 的空切片，实际: %v", batches[1])
+	}
+
+	// 第三个批次的帧数字段已经读出，子帧到达后才返回
+	batch, err := b.ReadBatch(frame("f"))
+	if err != nil || len(batch) != 1 || string(batch[0]) != "f" {
+		t.Fatalf("期望批次 [f]，实际: %q, %v", batch, err)
+	}
+}
+
+// TestBatchReader_Errors 测试帧数超限和子帧出错后一直返回同一个错误
+func TestBatchReader_Errors(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 4}
+
+	if _, err := NewBatchReader(config, 3); err == nil {
+		t.Error("期望帧数字段宽度为 3 时返回错误")
+	}
+
+	b, err := NewBatchReader(config, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.MaxCount = 2
+	if _, err := b.ReadBatch([]byte{3}); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("期望 ErrBatchTooLarge，实际: %v", err)
+	}
+
+	b, _ = NewBatchReader(config, 1)
+	_, err = b.ReadBatch([]byte{2, 0, 1, 'a', 0, 9})
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if _, again := b.ReadBatch(nil); again != err {
+		t.Errorf("期望之后一直返回同一个错误，实际: %v", again)
+	}
+}
@@ -0,0 +1,156 @@
+package frame
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// ReadFrameFromBufio 从 br 中读取一个完整帧，头部通过 br.Peek 解析，body 直接读入返回的切片，
+// 不经过 Frame 的缓冲区，避免在 bufio.Reader 之上再缓冲一次
+// - 头部必须能放进 br 的缓冲区，否则返回包装了 bufio.ErrBufferFull 的错误；body 的大小不受 br 缓冲区的限制
+// - 长度检查（MaxFrameLength 等）在读取 body 之前完成，超过上限时返回 ErrFrameTooLarge 且不消费任何数据
+// - 数据流在帧边界结束时返回 io.EOF，在帧中间结束时返回 io.ErrUnexpectedEOF
+// - 校验和、InitialBytesToStrip、CodecSelector 等与 ReadFrame 相同；不触发 HeaderConfig 中的回调
+//
+// 没有状态需要保存，同一个 br 上依次调用即可；不支持 AutoByteOrder 和 StreamBody
+func ReadFrameFromBufio(br *bufio.Reader, hc *HeaderConfig) ([]byte, error) {
+	bodyLen, headerLen, err := peekHeader(br, hc)
+	if err != nil {
+		return nil, err
+	}
+
+	trailerStart := headerLen + bodyLen
+	if trailerStart < headerLen || trailerStart > math.MaxInt-hc.TrailerLength {
+		return nil, fmt.Errorf("%w: header %d + body %d", ErrLengthOverflow, headerLen, bodyLen)
+	}
+
+	wire, err := readWire(br, headerLen, trailerStart+hc.TrailerLength, hc.MaxFrameLength > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rf, _, err := hc.splitFrame(wire, headerLen, trailerStart)
+	if err != nil {
+		return nil, err
+	}
+	if body, ok, err := hc.decodeBody(rf); ok {
+		return body, err
+	}
+	return rf.body, nil
+}
+
+// readWire 从 br 中读取一个 total 字节的完整帧，头部（headerLen 字节）已经在 br 的缓冲区中，body 直接读到它后面
+// 没有配置 MaxFrameLength（trusted 为 false）时长度字段不可信，与 Frame 相同最多预分配 maxPrealloc，
+// 之后随着数据到达按倍数扩容，防止一个恶意的头部在数据到达之前就占满内存
+func readWire(br *bufio.Reader, headerLen, total int, trusted bool) ([]byte, error) {
+	size := total
+	if !trusted {
+		size = min(size, max(headerLen, maxPrealloc))
+	}
+	wire := make([]byte, headerLen, size)
+	n, _ := io.ReadFull(br, wire)
+	if n != headerLen {
+		return nil, io.ErrUnexpectedEOF
+	}
+	for len(wire) < total {
+		if len(wire) == cap(wire) {
+			wire = slices.Grow(wire, min(total-len(wire), max(len(wire), 512)))
+		}
+		end := min(cap(wire), total)
+		if _, err := io.ReadFull(br, wire[len(wire):end]); err != nil {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		wire = wire[:end]
+	}
+	return wire, nil
+}
+
+// peekHeader 用 br.Peek 逐步取得足够的数据来解析头部，不消费任何数据
+func peekHeader(br *bufio.Reader, hc *HeaderConfig) (bodyLen, headerLen int, err error) {
+	n := 1
+	for {
+		buf, peekErr := br.Peek(n)
+		bodyLen, headerLen, ok, err := hc.parseHeader(buf, hc.ByteOrder, hc.MaxFrameLength)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ok {
+			return bodyLen, headerLen, nil
+		}
+
+		switch {
+		case peekErr == nil:
+		case errors.Is(peekErr, bufio.ErrBufferFull):
+			return 0, 0, fmt.Errorf("frame header does not fit in the bufio.Reader buffer: %w", peekErr)
+		case peekErr == io.EOF && len(buf) > 0:
+			return 0, 0, io.ErrUnexpectedEOF
+		default:
+			return 0, 0, peekErr
+		}
+		// 已经缓冲的数据一次全部交给 parseHeader，不够时再多要一个字节
+		n = max(n+1, br.Buffered())
+	}
+}
+
+// SplitFunc 返回按 hc 切分帧的 bufio.SplitFunc，可以用 bufio.Scanner 逐个取出帧：scanner.Split(frame.SplitFunc(hc))
+// - 每个 token 为一个帧的 body（与 ReadFrame 相同，见 InitialBytesToStrip），引用 Scanner 的缓冲区，下一次 Scan 之后失效，需要保留时自行拷贝
+// - 长度检查在 body 到达之前完成，超过 MaxFrameLength 等限制时 Scan 返回 false，Err 返回对应的错误（例如 ErrFrameTooLarge）
+// - 数据流在帧边界结束时 Scan 正常结束，在帧中间结束时 Err 返回 io.ErrUnexpectedEOF
+// - 校验和、CodecSelector 等与 ReadFrame 相同；不触发 HeaderConfig 中的回调，不支持 AutoByteOrder 和 StreamBody
+//
+// Scanner 要求整个帧（头部 + body + 校验和 + 尾部）都能放进它的缓冲区，缓冲区上限默认为 bufio.MaxScanTokenSize，
+// 更大的帧会让 Scan 以 bufio.ErrTooLong 结束。MaxFrameLength 较大时可以使用 NewScanner，它按 MaxFrameLength 设置缓冲区上限
+func SplitFunc(hc *HeaderConfig) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		bodyLen, headerLen, ok, err := hc.parseHeader(data, hc.ByteOrder, hc.MaxFrameLength)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		trailerStart := headerLen + bodyLen
+		if ok && (trailerStart < headerLen || trailerStart > math.MaxInt-hc.TrailerLength) {
+			return 0, nil, fmt.Errorf("%w: header %d + body %d", ErrLengthOverflow, headerLen, bodyLen)
+		}
+		if !ok || len(data) < trailerStart+hc.TrailerLength {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+
+		wire := data[:trailerStart+hc.TrailerLength]
+		rf, _, err := hc.splitFrame(wire, headerLen, trailerStart)
+		if err != nil {
+			return 0, nil, err
+		}
+		if body, ok, err := hc.decodeBody(rf); ok {
+			return len(wire), body, err
+		}
+		return len(wire), rf.body, nil
+	}
+}
+
+// NewScanner 返回一个从 r 读取、按 SplitFunc(hc) 切分帧的 bufio.Scanner
+// 配置了 MaxFrameLength 且最大的帧放不进默认的 bufio.MaxScanTokenSize 时，把缓冲区上限提高到能容纳最大的帧；
+// MaxFrameLength 为 0 时保持 Scanner 的默认上限
+func NewScanner(r io.Reader, hc *HeaderConfig) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Split(SplitFunc(hc))
+	if hc.MaxFrameLength > 0 {
+		wire := hc.maxEncodedLen(hc.MaxFrameLength) + max(hc.LengthFieldOffset, 0) + hc.fieldsLen() + hc.TrailerLength
+		if wire > bufio.MaxScanTokenSize {
+			sc.Buffer(nil, wire)
+		}
+	}
+	return sc
+}
@@ -0,0 +1,136 @@
+package frame
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"testing/iotest"
+)
+
+// TestReadFrameFromBufio 测试从 bufio.Reader 中直接读取帧
+func TestReadFrameFromBufio(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 256}
+	large := bytes.Repeat([]byte{'x'}, 100) // 超过 bufio 缓冲区的 body
+	var data []byte
+	for _, body := range [][]byte{[]byte("hi"), large, {}} {
+		data, _ = config.AppendFrame(data, body)
+	}
+
+	// 每次只读一个字节，头部也会分多次到达
+	br := bufio.NewReaderSize(iotest.OneByteReader(bytes.NewReader(data)), 16)
+	for _, want := range [][]byte{[]byte("hi"), large, {}} {
+		body, err := ReadFrameFromBufio(br, config)
+		if err != nil || body == nil || !bytes.Equal(body, want) {
+			t.Fatalf("期望 %d 字节的 body，实际: %d, %v", len(want), len(body), err)
+		}
+	}
+	if _, err := ReadFrameFromBufio(br, config); err != io.EOF {
+		t.Errorf("帧边界结束时期望 io.EOF，实际: %v", err)
+	}
+
+	// 在帧中间结束
+	br = bufio.NewReader(bytes.NewReader([]byte{0x00, 0x05, 'a'}))
+	if _, err := ReadFrameFromBufio(br, config); err != io.ErrUnexpectedEOF {
+		t.Errorf("期望 io.ErrUnexpectedEOF，实际: %v", err)
+	}
+}
+
+// TestReadFrameFromBufio_Errors 测试长度超限和头部放不进缓冲区
+func TestReadFrameFromBufio_Errors(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8}
+	br := bufio.NewReader(bytes.NewReader([]byte{0x10, 0x00, 'a', 'b'}))
+	if _, err := ReadFrameFromBufio(br, config); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if br.Buffered() != 4 {
+		t.Errorf("超过上限时不应消费任何数据，剩余: %d", br.Buffered())
+	}
+
+	config = &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, Magic: bytes.Repeat([]byte{0xAB}, 20)}
+	data, _ := config.Encode([]byte("hi"))
+	br = bufio.NewReaderSize(bytes.NewReader(data), 16)
+	if _, err := ReadFrameFromBufio(br, config); !errors.Is(err, bufio.ErrBufferFull) {
+		t.Errorf("头部放不进缓冲区时期望 bufio.ErrBufferFull，实际: %v", err)
+	}
+
+	// 没有 MaxFrameLength 时不能按不可信的长度字段一次性分配
+	config = &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 4}
+	br = bufio.NewReader(bytes.NewReader([]byte{0x7F, 0xFF, 0xFF, 0xF0, 'a', 'b'}))
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := ReadFrameFromBufio(br, config); err != io.ErrUnexpectedEOF {
+		t.Errorf("期望 io.ErrUnexpectedEOF，实际: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("截断的帧不应按长度字段分配内存，实际分配了 %d 字节", n)
+	}
+}
+
+// TestSplitFunc 测试配合 bufio.Scanner 逐个取出帧
+func TestSplitFunc(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 256}
+	var data []byte
+	for _, body := range []string{"hi", "", "hello"} {
+		data, _ = config.AppendFrame(data, []byte(body))
+	}
+
+	sc := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(data)))
+	sc.Split(SplitFunc(config))
+	var frames []string
+	for sc.Scan() {
+		frames = append(frames, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("帧边界结束时不应返回错误，实际: %v", err)
+	}
+	if len(frames) != 3 || frames[0] != "hi" || frames[1] != "" || frames[2] != "hello" {
+		t.Errorf("期望 [hi  hello]，实际: %q", frames)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"在帧中间结束", []byte{0x00, 0x05, 'a'}, io.ErrUnexpectedEOF},
+		{"超过MaxFrameLength", []byte{0x10, 0x00}, ErrFrameTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := bufio.NewScanner(bytes.NewReader(tt.data))
+			sc.Split(SplitFunc(config))
+			if sc.Scan() {
+				t.Fatal("不应取出任何帧")
+			}
+			if !errors.Is(sc.Err(), tt.want) {
+				t.Errorf("期望 %v，实际: %v", tt.want, sc.Err())
+			}
+		})
+	}
+}
+
+// TestNewScanner 测试 MaxFrameLength 超过 bufio.MaxScanTokenSize 时提高 Scanner 的缓冲区上限
+func TestNewScanner(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 4, MaxFrameLength: 1 << 20}
+	large := bytes.Repeat([]byte{'x'}, bufio.MaxScanTokenSize*2)
+	data, _ := config.Encode(large)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Split(SplitFunc(config))
+	if sc.Scan() || !errors.Is(sc.Err(), bufio.ErrTooLong) {
+		t.Fatalf("默认缓冲区上限期望 bufio.ErrTooLong，实际: %v", sc.Err())
+	}
+
+	sc = NewScanner(bytes.NewReader(data), config)
+	if !sc.Scan() || !bytes.Equal(sc.Bytes(), large) {
+		t.Fatalf("期望取出 %d 字节的帧，实际: %d, %v", len(large), len(sc.Bytes()), sc.Err())
+	}
+	if sc.Scan() || sc.Err() != nil {
+		t.Errorf("期望正常结束，实际: %v", sc.Err())
+	}
+}
@@ -0,0 +1,126 @@
+// Package cobs 按 COBS（Consistent Overhead Byte Stuffing）编码分帧
+//
+// COBS 把任意数据编码成不含 0x00 的字节序列，再用一个 0x00 作为帧结束分隔符，
+// 每 254 字节最多增加 1 字节开销。适合串口等需要可靠分隔符的字节流：任何位置丢字节或者多出字节，
+// 下一个 0x00 之后都能重新同步。用法与 frame.DelimiterFrame 相同：每次输入读到的数据，数据不足时返回 nil，等待下次补充
+package cobs
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	// ErrCorrupt 两个分隔符之间的数据不是合法的 COBS 编码（某个块的长度超出了帧的末尾）
+	ErrCorrupt = errors.New("cobs frame corrupt")
+	// ErrFrameTooLarge 编码后的帧（不含分隔符）超过 Reader.MaxFrameLength
+	ErrFrameTooLarge = errors.New("cobs frame too large")
+)
+
+// Encode 返回 data 的 COBS 编码，结果不含 0x00，末尾加上 0x00 分隔符，可以直接写入 conn
+func Encode(data []byte) []byte {
+	dst := make([]byte, 1, len(data)+len(data)/254+2)
+	codeIdx, code := 0, byte(1)
+	for i, b := range data {
+		if b == 0 {
+			dst[codeIdx] = code
+			codeIdx, code = len(dst), 1
+			dst = append(dst, 0)
+			continue
+		}
+		dst = append(dst, b)
+		code++
+		// 满 254 个非 0 字节的块不隐含 0x00，后面还有数据时才开始下一个块
+		if code == 0xFF && i < len(data)-1 {
+			dst[codeIdx] = code
+			codeIdx, code = len(dst), 1
+			dst = append(dst, 0)
+		}
+	}
+	dst[codeIdx] = code
+	return append(dst, 0)
+}
+
+// Decode 还原一个 COBS 编码的帧，enc 不含末尾的 0x00 分隔符，中间出现 0x00 或者块长度超出 enc 时返回 ErrCorrupt
+func Decode(enc []byte) ([]byte, error) {
+	out := make([]byte, 0, len(enc))
+	for i := 0; i < len(enc); {
+		code := int(enc[i])
+		if code == 0 || i+code > len(enc) || bytes.IndexByte(enc[i+1:i+code], 0) >= 0 {
+			return nil, ErrCorrupt
+		}
+		out = append(out, enc[i+1:i+code]...)
+		i += code
+		// 每个不满 254 字节的块后面隐含一个 0x00，最后一个块除外
+		if code < 0xFF && i < len(enc) {
+			out = append(out, 0)
+		}
+	}
+	return out, nil
+}
+
+// Reader 从连接读到的数据中按 0x00 分隔符依次取出 COBS 帧并还原
+type Reader struct {
+	// MaxFrameLength 编码后的帧（不含分隔符）的最大长度，0 表示不限制
+	// 超过时返回 ErrFrameTooLarge，并丢弃这个帧直到下一个分隔符
+	MaxFrameLength int
+
+	buf        []byte
+	scan       int  // buf 中已确认不包含分隔符的前缀长度，避免重复扫描
+	discarding bool // 正在丢弃超长的帧，遇到下一个分隔符后恢复
+}
+
+// ReadFrame 输入一次读到的数据，输出一个还原后的完整帧
+// - 如果还没有遇到分隔符，返回 (nil, nil)，等待下次补充
+// - 如果有多个帧，调用方需要多次调用 ReadFrame（raw 为 nil）才能依次取出
+// - 连续的分隔符之间没有数据时直接跳过（发送方常用单独的 0x00 冲刷链路）；空的 data 编码为 01 00，返回非 nil 的空切片
+// - 编码不合法时丢弃这个帧并返回 ErrCorrupt，之后可以继续读取，下一个帧不受影响
+// - raw 总是被拷贝到内部缓冲区，返回的帧是新分配的，不引用 raw
+func (r *Reader) ReadFrame(raw []byte) ([]byte, error) {
+	r.buf = append(r.buf, raw...)
+
+	for {
+		idx := bytes.IndexByte(r.buf[r.scan:], 0)
+		if idx < 0 {
+			if r.discarding || (r.MaxFrameLength > 0 && len(r.buf) > r.MaxFrameLength) {
+				// 超长的帧不再缓冲，剩余部分到达时直接丢弃，直到下一个分隔符
+				wasDiscarding := r.discarding
+				r.buf, r.scan, r.discarding = r.buf[:0], 0, true
+				if !wasDiscarding {
+					return nil, ErrFrameTooLarge
+				}
+				return nil, nil
+			}
+			r.scan = len(r.buf)
+			return nil, nil
+		}
+		idx += r.scan
+
+		enc := r.buf[:idx]
+		discarding := r.discarding
+		r.discarding = false
+		if discarding || len(enc) == 0 {
+			r.consume(idx + 1)
+			continue
+		}
+		if r.MaxFrameLength > 0 && len(enc) > r.MaxFrameLength {
+			r.consume(idx + 1)
+			return nil, ErrFrameTooLarge
+		}
+
+		frame, err := Decode(enc)
+		r.consume(idx + 1)
+		return frame, err
+	}
+}
+
+// consume 从缓冲区中移除开头的 n 个字节
+func (r *Reader) consume(n int) {
+	r.buf = r.buf[:copy(r.buf, r.buf[n:])]
+	r.scan = 0
+}
+
+// Buffered 返回缓冲区中尚未遇到分隔符的字节数
+func (r *Reader) Buffered() int {
+	return len(r.buf)
+}
@@ -0,0 +1,130 @@
+package cobs
+
+import (
+	"bytes"
+	"testing"
+)
+
+// seq 返回从 from 到 to（含）的连续字节
+func seq(from, to int) []byte {
+	var b []byte
+	for i := from; i <= to; i++ {
+		b = append(b, byte(i))
+	}
+	return b
+}
+
+// cat 拼接多个字节切片
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// vectors COBS 的标准测试向量（编码结果含末尾的 0x00 分隔符）
+var vectors = []struct {
+	name string
+	data []byte
+	enc  []byte
+}{
+	{"空数据", []byte{}, []byte{0x01, 0x00}},
+	{"单个0", []byte{0x00}, []byte{0x01, 0x01, 0x00}},
+	{"两个0", []byte{0x00, 0x00}, []byte{0x01, 0x01, 0x01, 0x00}},
+	{"0包围", []byte{0x00, 0x11, 0x00}, []byte{0x01, 0x02, 0x11, 0x01, 0x00}},
+	{"中间有0", []byte{0x11, 0x22, 0x00, 0x33}, []byte{0x03, 0x11, 0x22, 0x02, 0x33, 0x00}},
+	{"没有0", []byte{0x11, 0x22, 0x33, 0x44}, []byte{0x05, 0x11, 0x22, 0x33, 0x44, 0x00}},
+	{"末尾多个0", []byte{0x11, 0x00, 0x00, 0x00}, []byte{0x02, 0x11, 0x01, 0x01, 0x01, 0x00}},
+	{"254个非0字节", seq(0x01, 0xFE), cat([]byte{0xFF}, seq(0x01, 0xFE), []byte{0x00})},
+	{"0后跟254个非0字节", cat([]byte{0x00}, seq(0x01, 0xFE)), cat([]byte{0x01, 0xFF}, seq(0x01, 0xFE), []byte{0x00})},
+	{"255个非0字节", seq(0x01, 0xFF), cat([]byte{0xFF}, seq(0x01, 0xFE), []byte{0x02, 0xFF, 0x00})},
+	{"254个非0字节后跟0", cat(seq(0x02, 0xFF), []byte{0x00}), cat([]byte{0xFF}, seq(0x02, 0xFF), []byte{0x01, 0x01, 0x00})},
+	{"253个非0字节后跟0和非0", cat(seq(0x03, 0xFF), []byte{0x00, 0x01}), cat([]byte{0xFE}, seq(0x03, 0xFF), []byte{0x02, 0x01, 0x00})},
+}
+
+// TestEncodeDecodeVectors 使用标准测试向量检查编码和解码
+func TestEncodeDecodeVectors(t *testing.T) {
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			enc := Encode(v.data)
+			if !bytes.Equal(enc, v.enc) {
+				t.Fatalf("编码错误\n期望: % x\n实际: % x", v.enc, enc)
+			}
+			if bytes.IndexByte(enc[:len(enc)-1], 0) >= 0 {
+				t.Fatalf("编码结果中不应出现 0x00: % x", enc)
+			}
+			dec, err := Decode(enc[:len(enc)-1])
+			if err != nil || !bytes.Equal(dec, v.data) {
+				t.Fatalf("解码错误，期望: % x，实际: % x, %v", v.data, dec, err)
+			}
+		})
+	}
+}
+
+// TestReader 测试逐字节到达时按分隔符取出所有测试向量
+func TestReader(t *testing.T) {
+	var stream []byte
+	for _, v := range vectors {
+		stream = append(stream, v.enc...)
+	}
+	// 额外的分隔符之间没有数据，直接跳过
+	stream = append([]byte{0x00}, stream...)
+
+	r := &Reader{}
+	var got [][]byte
+	for i := range stream {
+		frame, err := r.ReadFrame(stream[i : i+1])
+		if err != nil {
+			t.Fatalf("第 %d 字节: %v", i, err)
+		}
+		if frame != nil {
+			got = append(got, frame)
+		}
+	}
+	if len(got) != len(vectors) {
+		t.Fatalf("期望 %d 个帧，实际: %d", len(vectors), len(got))
+	}
+	for i, v := range vectors {
+		if got[i] == nil || !bytes.Equal(got[i], v.data) {
+			t.Errorf("%s: 期望 % x，实际: % x", v.name, v.data, got[i])
+		}
+	}
+	if r.Buffered() != 0 {
+		t.Errorf("期望缓冲区为空，实际剩余 %d 字节", r.Buffered())
+	}
+}
+
+// TestReaderErrors 测试损坏和超长的帧被丢弃后能在下一个分隔符之后恢复
+func TestReaderErrors(t *testing.T) {
+	r := &Reader{MaxFrameLength: 8}
+
+	// 块长度 0x05 超出了帧的末尾
+	data := cat([]byte{0x05, 0x11, 0x22, 0x00}, Encode([]byte("ok")))
+	if _, err := r.ReadFrame(data); err != ErrCorrupt {
+		t.Fatalf("期望 ErrCorrupt，实际: %v", err)
+	}
+	if frame, err := r.ReadFrame(nil); err != nil || string(frame) != "ok" {
+		t.Fatalf("期望 ok，实际: %q, %v", frame, err)
+	}
+
+	// 超长的帧分多次到达，只报告一次，之后的部分直接丢弃
+	long := Encode(bytes.Repeat([]byte("x"), 20))
+	if _, err := r.ReadFrame(long[:10]); err != ErrFrameTooLarge {
+		t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if frame, err := r.ReadFrame(long[10:15]); frame != nil || err != nil {
+		t.Fatalf("期望继续丢弃超长帧，实际: %q, %v", frame, err)
+	}
+	if r.Buffered() != 0 {
+		t.Errorf("丢弃期间不应缓冲数据，实际缓冲 %d 字节", r.Buffered())
+	}
+	frame, err := r.ReadFrame(cat(long[15:], Encode([]byte("next"))))
+	if err != nil || string(frame) != "next" {
+		t.Fatalf("期望 next，实际: %q, %v", frame, err)
+	}
+
+	// 一次到达的完整超长帧同样被拒绝
+	if _, err := r.ReadFrame(long); err != ErrFrameTooLarge {
+		t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if r.Buffered() != 0 {
+		t.Errorf("期望超长帧被丢弃，实际剩余 %d 字节", r.Buffered())
+	}
+}
@@ -0,0 +1,78 @@
+package frame
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// BodyCodec 对 body 做压缩等变换，见 HeaderConfig.CodecSelector
+type BodyCodec interface {
+	// Decode 把线路上的 body 还原为原始数据，返回的切片不能引用 body
+	Decode(body []byte) ([]byte, error)
+	// Encode 是 Decode 的逆运算，编码时由调用方在 Encode 之前调用并同时在头部设置相应的标志
+	Encode(body []byte) ([]byte, error)
+}
+
+// FlateCodec 使用 compress/flate 压缩 body 的 BodyCodec
+type FlateCodec struct {
+	Level     int // 压缩级别，取值同 flate.NewWriter，0 表示 flate.DefaultCompression
+	MaxLength int // 解压后允许的最大长度，超过时返回 ErrFrameTooLarge，防止压缩炸弹；0 表示不限制
+}
+
+func (c FlateCodec) Decode(body []byte) ([]byte, error) {
+	var r io.Reader = flate.NewReader(bytes.NewReader(body))
+	if c.MaxLength > 0 {
+		// 多读一个字节用来判断是否超过上限
+		r = io.LimitReader(r, int64(c.MaxLength)+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.MaxLength > 0 && len(data) > c.MaxLength {
+		return nil, ErrFrameTooLarge
+	}
+	return data, nil
+}
+
+func (c FlateCodec) Encode(body []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBody 按 CodecSelector 为 rf 选出的 BodyCodec 还原 body，没有配置或没有选中 codec 时 ok 为 false
+func (hc *HeaderConfig) decodeBody(rf rawFrame) (body []byte, ok bool, err error) {
+	if hc.CodecSelector == nil {
+		return nil, false, nil
+	}
+	codec := hc.CodecSelector(rf.header)
+	if codec == nil {
+		return nil, false, nil
+	}
+	body, err = codec.Decode(rf.body)
+	if err != nil {
+		return nil, true, fmt.Errorf("decode body: %w", err)
+	}
+	if body == nil {
+		// nil 表示数据不足，空 body 必须返回非 nil 的切片
+		body = []byte{}
+	}
+	return body, true, nil
+}
@@ -0,0 +1,81 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestFrame_ReadFrame_CodecSelector 测试按头部中的标志位选择是否解压
+func TestFrame_ReadFrame_CodecSelector(t *testing.T) {
+	codec := FlateCodec{}
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		LengthFieldOffset: 1, // 第一个字节为标志位，1 表示压缩
+		CodecSelector: func(header []byte) BodyCodec {
+			if header[0]&1 != 0 {
+				return codec
+			}
+			return nil
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := bytes.Repeat([]byte("hello "), 20)
+	compressed, err := codec.Encode(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, _ := config.Encode(append([]byte{0x01}, compressed...))
+	raw, _ := config.Encode(append([]byte{0x00}, "raw"...))
+
+	frame := &Frame{Hc: config}
+	body, err := frame.ReadFrame(append(packed, raw...))
+	if err != nil || !bytes.Equal(body, plain) {
+		t.Fatalf("压缩帧应被解压: %q, %v", body, err)
+	}
+	body, err = frame.ReadFrame(nil)
+	if err != nil || string(body) != "raw" {
+		t.Fatalf("未压缩的帧应原样返回: %q, %v", body, err)
+	}
+
+	// 解压失败的帧被丢弃，之后可以继续读取
+	bad, _ := config.Encode([]byte{0x01, 0xFF, 0xFF})
+	if _, err := frame.ReadFrame(append(bad, raw...)); err == nil {
+		t.Fatal("解压失败应返回错误")
+	}
+	if body, err := frame.ReadFrame(nil); err != nil || string(body) != "raw" {
+		t.Errorf("期望继续读取下一个帧: %q, %v", body, err)
+	}
+
+	// ReadFrameInto 按解压后的长度检查 dst
+	frame.buf = append(frame.buf, packed...)
+	dst := make([]byte, 8)
+	_, _, err = frame.ReadFrameInto(nil, dst)
+	var short *ShortBufferError
+	if !errors.As(err, &short) || short.Need != len(plain) {
+		t.Fatalf("期望 ShortBufferError，实际: %v", err)
+	}
+	dst = make([]byte, short.Need)
+	if n, ok, err := frame.ReadFrameInto(nil, dst); !ok || err != nil || !bytes.Equal(dst[:n], plain) {
+		t.Errorf("重试应读取解压后的 body: %v, %v", ok, err)
+	}
+}
+
+// TestFlateCodec_MaxLength 测试解压后的长度上限
+func TestFlateCodec_MaxLength(t *testing.T) {
+	compressed, err := FlateCodec{}.Encode(make([]byte, 1<<16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (FlateCodec{MaxLength: 1024}).Decode(compressed); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if body, err := (FlateCodec{MaxLength: 1 << 16}).Decode(compressed); err != nil || len(body) != 1<<16 {
+		t.Errorf("恰好等于上限时应正常解压: %d, %v", len(body), err)
+	}
+}
@@ -0,0 +1,60 @@
+package frame
+
+import (
+	"io"
+	"net"
+)
+
+// ReadFromConn 从 conn 循环读取，直到得到一个完整帧或者出错
+// - 缓冲区中已有完整帧时直接返回，不读取 conn
+// - conn 在帧边界结束时返回 io.EOF，在帧中间结束时返回 io.ErrUnexpectedEOF
+// - 配置了 HighWaterMark 时每次最多读取缓冲区剩余的空间，缓冲区满了仍凑不出完整帧时返回 ErrHighWaterMark
+// - conn 上已设置的读超时照常生效，超时错误原样返回，已读到的数据保留在缓冲区中，可以再次调用
+//
+// 同一个 Frame 上不能并发调用 ReadFromConn；需要从 io.Reader 连续读取时也可以使用 FrameReader
+func (f *Frame) ReadFromConn(conn net.Conn) ([]byte, error) {
+	body, err := f.ReadFrame(nil)
+	for body == nil && err == nil {
+		scratch := f.readBuffer()
+		room := f.room(len(scratch))
+		if room == 0 {
+			return nil, ErrHighWaterMark
+		}
+
+		n, rerr := conn.Read(scratch[:room])
+		if n > 0 {
+			body, err = f.ReadFrame(scratch[:n])
+		}
+		if body != nil || err != nil {
+			// 先返回已经完整的帧，conn 的读错误（例如 EOF）会在下次调用时再次出现
+			break
+		}
+		if rerr != nil {
+			if rerr == io.EOF && f.buffered() > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, rerr
+		}
+	}
+	return body, err
+}
+
+// readBuffer 返回 ReadFromConn 的读缓冲区，首次使用时分配
+// 在锁内取出切片，之后只使用这份拷贝：TrimToFit 可能在 conn.Read 阻塞期间从其他 goroutine 释放 f.scratch
+func (f *Frame) readBuffer() []byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.scratch == nil {
+		f.scratch = make([]byte, defaultReadBufferSize)
+	}
+	return f.scratch
+}
+
+// room 返回按 HighWaterMark 缓冲区还能接收的字节数，最多为 n
+func (f *Frame) room(n int) int {
+	mark := f.Hc.HighWaterMark
+	if mark == 0 {
+		return n
+	}
+	return min(n, max(mark-f.buffered(), 0))
+}
@@ -0,0 +1,95 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestFrame_ReadFromConn 测试从 net.Conn 循环读取完整帧
+func TestFrame_ReadFromConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		// 帧被拆成多次写入，第二个帧不完整
+		for _, chunk := range [][]byte{{0x00}, {0x02, 'h'}, {'i', 0x00, 0x03, 'f'}, {'o', 'o'}, {0x00, 0x05, 'x'}} {
+			if _, err := client.Write(chunk); err != nil {
+				return
+			}
+		}
+		client.Close()
+	}()
+
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+	for _, want := range []string{"hi", "foo"} {
+		body, err := frame.ReadFromConn(server)
+		if err != nil || string(body) != want {
+			t.Fatalf("期望 %q，实际: %q, %v", want, body, err)
+		}
+	}
+	if _, err := frame.ReadFromConn(server); err != io.ErrUnexpectedEOF {
+		t.Errorf("帧中间断开期望 io.ErrUnexpectedEOF，实际: %v", err)
+	}
+}
+
+// TestFrame_ReadFromConn_Deadline 测试 conn 上已设置的读超时
+func TestFrame_ReadFromConn_Deadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() { _, _ = client.Write([]byte{0x00, 0x02, 'h'}) }()
+
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+	_ = server.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := frame.ReadFromConn(server); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("期望读超时，实际: %v", err)
+	}
+
+	// 超时前读到的数据保留在缓冲区中
+	_ = server.SetReadDeadline(time.Time{})
+	go func() { _, _ = client.Write([]byte{'i'}) }()
+	body, err := frame.ReadFromConn(server)
+	if err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+}
+
+// TestFrame_ReadFromConn_ConcurrentTrimToFit 测试 ReadFromConn 阻塞在 conn.Read 时从其他 goroutine 调用 TrimToFit
+// 需要配合 go test -race 运行
+func TestFrame_ReadFromConn_ConcurrentTrimToFit(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	frame, _ := NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			frame.TrimToFit()
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+	go func() {
+		for i := 0; i < 20; i++ {
+			if _, err := client.Write([]byte{0x00, 0x02, 'h', 'i'}); err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		body, err := frame.ReadFromConn(server)
+		if err != nil || string(body) != "hi" {
+			t.Fatalf("第 %d 个帧期望 hi，实际: %q, %v", i, body, err)
+		}
+	}
+	<-done
+}
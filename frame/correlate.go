@@ -0,0 +1,117 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrUnknownSequence 收到的帧的序号没有对应的等待者，通常是等待者已经取消或者超时之后才到达的响应
+var ErrUnknownSequence = errors.New("unknown sequence number")
+
+// Correlator 从 FrameReader 中读取响应帧，按头部中的序号交给等待这个序号的调用方，用于请求/响应一一对应的协议
+//
+//	ch := c.Wait(seq) // 先登记再发送请求，避免响应先于登记到达
+//	send(seq, request)
+//	body, ok := <-ch
+type Correlator struct {
+	fr    *FrameReader
+	field FieldSpec
+
+	lock    sync.Mutex
+	pending map[uint64]chan []byte
+	closed  bool
+}
+
+// NewCorrelator 创建一个从 fr 读取帧的 Correlator，field 描述序号字段在头部中的位置，规则与 NewDispatcher 相同
+func NewCorrelator(fr *FrameReader, field FieldSpec) (*Correlator, error) {
+	if err := fr.frame.Hc.validateField(field); err != nil {
+		return nil, err
+	}
+	return &Correlator{
+		fr:      fr,
+		field:   field,
+		pending: make(map[uint64]chan []byte),
+	}, nil
+}
+
+// Wait 登记等待序号为 seq 的响应，返回的 channel 在响应到达时收到它的 body
+// - 每个序号同时只能有一个等待者，重复登记时之前的 channel 会被关闭
+// - Run 结束后所有未完成的 channel 都会被关闭，之后登记的 channel 直接返回一个已关闭的 channel
+// - 不再需要响应（例如超时）时应调用 Cancel，否则这个序号会一直占用内存
+func (c *Correlator) Wait(seq uint64) <-chan []byte {
+	ch := make(chan []byte, 1)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		close(ch)
+		return ch
+	}
+	if old, ok := c.pending[seq]; ok {
+		close(old)
+	}
+	c.pending[seq] = ch
+	return ch
+}
+
+// Cancel 取消对序号 seq 的等待并关闭对应的 channel，没有登记时不做任何事
+func (c *Correlator) Cancel(seq uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ch, ok := c.pending[seq]; ok {
+		delete(c.pending, seq)
+		close(ch)
+	}
+}
+
+// Next 读取一个帧并交给等待它的序号的调用方，返回这个帧的序号
+// 序号没有等待者时返回包装了 ErrUnknownSequence 的错误，这个帧被丢弃，可以继续调用 Next
+func (c *Correlator) Next(ctx context.Context) (uint64, error) {
+	seq, body, err := c.fr.nextField(ctx, c.field)
+	if err != nil {
+		return 0, err
+	}
+
+	c.lock.Lock()
+	ch, ok := c.pending[seq]
+	delete(c.pending, seq)
+	c.lock.Unlock()
+
+	if !ok {
+		return seq, fmt.Errorf("%w: %d", ErrUnknownSequence, seq)
+	}
+	// channel 有一个缓冲区且只会发送一次，不会阻塞
+	ch <- body
+	return seq, nil
+}
+
+// Run 持续读取响应帧直到出错或者 ctx 结束，没有等待者的帧会被忽略；数据流在帧边界正常结束时返回 nil
+// Run 返回时关闭所有仍在等待的 channel
+func (c *Correlator) Run(ctx context.Context) error {
+	defer c.close()
+	for {
+		if _, err := c.Next(ctx); err != nil {
+			if errors.Is(err, ErrUnknownSequence) {
+				continue
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// close 关闭所有仍在等待的 channel，之后的 Wait 直接返回已关闭的 channel
+func (c *Correlator) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for seq, ch := range c.pending {
+		delete(c.pending, seq)
+		close(ch)
+	}
+	c.closed = true
+}
@@ -0,0 +1,68 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestCorrelator 测试按序号把响应交给对应的等待者
+func TestCorrelator(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder: binary.BigEndian,
+		Fields: []FieldSpec{
+			{Name: "seq", Offset: 0, Width: 4},
+			{Name: "length", Offset: 4, Width: 2, Length: true},
+		},
+	}
+	// 响应乱序到达，序号 3 已经取消，被忽略
+	data := []byte{
+		0, 0, 0, 2, 0x00, 0x01, 'b',
+		0, 0, 0, 3, 0x00, 0x01, 'c',
+		0, 0, 0, 1, 0x00, 0x01, 'a',
+	}
+
+	c, err := NewCorrelator(NewReader(bytes.NewReader(data), config), FieldSpec{Offset: 0, Width: 4})
+	if err != nil {
+		t.Fatalf("创建 Correlator 失败: %v", err)
+	}
+	first, second := c.Wait(1), c.Wait(2)
+	cancelled := c.Wait(3)
+	c.Cancel(3)
+	if _, ok := <-cancelled; ok {
+		t.Error("Cancel 之后 channel 应被关闭")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background()) }()
+
+	if body := <-first; string(body) != "a" {
+		t.Errorf("序号 1 期望 a，实际: %q", body)
+	}
+	if body := <-second; string(body) != "b" {
+		t.Errorf("序号 2 期望 b，实际: %q", body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("数据流正常结束时 Run 应返回 nil，实际: %v", err)
+	}
+	if _, ok := <-c.Wait(4); ok {
+		t.Error("Run 结束后登记的 channel 应已关闭")
+	}
+}
+
+// TestCorrelator_Next 测试 Next 返回序号以及未登记的序号
+func TestCorrelator_Next(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: 4}
+	data := []byte{0, 0, 0, 9, 0x00, 0x01, 'x'}
+
+	c, err := NewCorrelator(NewReader(bytes.NewReader(data), config), FieldSpec{Offset: 0, Width: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := c.Next(context.Background())
+	if seq != 9 || !errors.Is(err, ErrUnknownSequence) {
+		t.Errorf("期望序号 9 和 ErrUnknownSequence，实际: %d, %v", seq, err)
+	}
+}
@@ -0,0 +1,194 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+type DelimiterConfig struct {
+	Delimiter      []byte // 帧结束分隔符，例如 []byte("\n") 或 []byte("\r\n")
+	StripDelimiter bool   // 返回的帧是否去掉分隔符
+	MaxFrameLength int    // 单帧最大长度（不含分隔符），0 表示不限制
+
+	// EscapeByte 转义字节，0 表示不转义（因此 0x00 不能作为转义字节）
+	// 开启后 body 中的转义字节和分隔符的第一个字节前面都要加上转义字节，使 body 可以包含任意二进制数据；
+	// ReadFrame 返回去掉转义之后的 body，Encode 负责加上转义。EscapeByte 不能出现在分隔符中
+	EscapeByte byte
+}
+
+// Encode 为 body 加上转义（配置了 EscapeByte 时）和分隔符，返回可以直接写入 conn 的完整帧
+// 没有配置 EscapeByte 而 body 中包含分隔符时返回错误
+func (dc *DelimiterConfig) Encode(body []byte) ([]byte, error) {
+	delim := dc.Delimiter
+	if len(delim) == 0 {
+		return nil, errors.New("empty delimiter")
+	}
+	if dc.EscapeByte == 0 {
+		if bytes.Contains(body, delim) {
+			return nil, errors.New("body contains delimiter")
+		}
+		return append(append(make([]byte, 0, len(body)+len(delim)), body...), delim...), nil
+	}
+	if bytes.IndexByte(delim, dc.EscapeByte) >= 0 {
+		return nil, errors.New("EscapeByte must not appear in delimiter")
+	}
+
+	frame := make([]byte, 0, len(body)+len(body)/8+len(delim))
+	for _, c := range body {
+		if c == dc.EscapeByte || c == delim[0] {
+			frame = append(frame, dc.EscapeByte)
+		}
+		frame = append(frame, c)
+	}
+	return append(frame, delim...), nil
+}
+
+// DelimiterFrame 按分隔符切分数据流，适用于行协议等没有长度前缀的场景
+type DelimiterFrame struct {
+	Dc         *DelimiterConfig
+	buf        []byte
+	scan       int  // buf 中已确认不包含分隔符起点的前缀长度，避免重复扫描
+	discarding bool // 正在丢弃超过 MaxFrameLength 的帧，遇到下一个分隔符后恢复
+	lock       sync.Mutex
+}
+
+// ReadFrame 输入一次读到的数据，输出分隔符之前的一个完整帧
+// - 如果还没有遇到分隔符，返回 (nil, nil)，等待下次补充
+// - 分隔符被拆分在两次读取之间时也能正确识别
+// - 如果有多个帧，调用方需要多次调用 ReadFrame 才能依次取出
+// - 返回的帧是新分配的，不引用内部缓冲区，可以保留或者修改
+// - 帧超过 MaxFrameLength 时返回一次 ErrFrameTooLarge，并丢弃这个帧直到下一个（未被转义的）分隔符，之后的帧照常返回；
+// 丢弃期间到达的数据不再缓冲，因此缓冲区不会超过 MaxFrameLength 加上一次输入的长度
+func (f *DelimiterFrame) ReadFrame(raw []byte) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delim := f.Dc.Delimiter
+	if len(delim) == 0 {
+		return nil, errors.New("empty delimiter")
+	}
+	esc := f.Dc.EscapeByte
+	if esc != 0 && bytes.IndexByte(delim, esc) >= 0 {
+		return nil, errors.New("EscapeByte must not appear in delimiter")
+	}
+
+	f.buf = append(f.buf, raw...)
+
+	for {
+		var idx, safe int
+		if esc != 0 {
+			idx, safe = f.findEscaped()
+		} else {
+			idx, safe = f.find()
+		}
+
+		if idx < 0 {
+			f.scan = safe
+			if f.discarding || (f.Dc.MaxFrameLength > 0 && safe > f.Dc.MaxFrameLength) {
+				// 已经确认不含分隔符的部分直接丢弃，只保留末尾可能是半个分隔符或转义序列的字节
+				wasDiscarding := f.discarding
+				f.consume(safe)
+				f.discarding = true
+				if !wasDiscarding {
+					return nil, ErrFrameTooLarge
+				}
+			}
+			return nil, nil
+		}
+
+		end := idx + len(delim)
+		if f.discarding {
+			// 超长帧的结尾，恢复正常解析
+			f.consume(end)
+			f.discarding = false
+			continue
+		}
+		if f.Dc.MaxFrameLength > 0 && idx > f.Dc.MaxFrameLength {
+			f.consume(end)
+			return nil, ErrFrameTooLarge
+		}
+
+		var frame []byte
+		if esc != 0 {
+			frame = f.unescape(idx)
+		} else {
+			n := end
+			if f.Dc.StripDelimiter {
+				n = idx
+			}
+			// 拷贝出来返回，调用方在返回的帧上 append 不会覆盖缓冲区中的下一个帧
+			frame = append(make([]byte, 0, n), f.buf[:n]...)
+		}
+
+		// 更新缓冲区，丢掉已消费的部分
+		f.buf = f.buf[end:]
+		f.scan = 0
+
+		return frame, nil
+	}
+}
+
+// consume 从缓冲区中移除开头的 n 个字节，把剩余数据移到开头，丢弃期间缓冲区不会越用越大，调用方需持有锁
+func (f *DelimiterFrame) consume(n int) {
+	f.buf = f.buf[:copy(f.buf, f.buf[n:])]
+	f.scan = 0
+}
+
+// find 在 buf 中查找分隔符，返回分隔符的位置（没有时为 -1）和下次开始扫描的位置，调用方需持有锁
+func (f *DelimiterFrame) find() (idx, safe int) {
+	delim := f.Dc.Delimiter
+	if idx = bytes.Index(f.buf[f.scan:], delim); idx >= 0 {
+		return idx + f.scan, 0
+	}
+	// 末尾可能是被拆开的半个分隔符，下次从这里重新扫描
+	return -1, max(0, len(f.buf)-len(delim)+1)
+}
+
+// findEscaped 是开启 EscapeByte 时的 find，只查找没有被转义的分隔符，调用方需持有锁
+func (f *DelimiterFrame) findEscaped() (idx, safe int) {
+	delim, esc := f.Dc.Delimiter, f.Dc.EscapeByte
+
+	// scan 总是停在转义序列之外，转义字节落在本次数据末尾时停在它上面，等下次数据到达后再一起判断
+	i := f.scan
+	for i < len(f.buf) {
+		c := f.buf[i]
+		if c == esc {
+			if i+1 >= len(f.buf) {
+				break
+			}
+			i += 2
+			continue
+		}
+		if c == delim[0] {
+			if len(f.buf)-i < len(delim) {
+				break
+			}
+			if bytes.Equal(f.buf[i:i+len(delim)], delim) {
+				return i, 0
+			}
+		}
+		i++
+	}
+	return -1, i
+}
+
+// unescape 去掉 buf[:idx] 中的转义，返回新分配的帧（按 StripDelimiter 决定是否带上分隔符），调用方需持有锁
+func (f *DelimiterFrame) unescape(idx int) []byte {
+	delim, esc := f.Dc.Delimiter, f.Dc.EscapeByte
+
+	// 原地去掉转义，结果不会比原数据长
+	n := 0
+	for j := 0; j < idx; j++ {
+		if f.buf[j] == esc {
+			j++
+		}
+		f.buf[n] = f.buf[j]
+		n++
+	}
+	frame := append(make([]byte, 0, n+len(delim)), f.buf[:n]...)
+	if !f.Dc.StripDelimiter {
+		frame = append(frame, delim...)
+	}
+	return frame
+}
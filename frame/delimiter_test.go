@@ -0,0 +1,264 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestDelimiterFrame_ReadFrame 测试分隔符切分功能
+func TestDelimiterFrame_ReadFrame(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         *DelimiterConfig
+		inputData      [][]byte
+		expectedFrames [][]byte
+		expectedError  bool
+		errorMessage   string
+	}{
+		{
+			name:           "单行-保留分隔符",
+			config:         &DelimiterConfig{Delimiter: []byte("\n")},
+			inputData:      [][]byte{[]byte("hello\n")},
+			expectedFrames: [][]byte{[]byte("hello\n")},
+		},
+		{
+			name:           "单行-去掉分隔符",
+			config:         &DelimiterConfig{Delimiter: []byte("\n"), StripDelimiter: true},
+			inputData:      [][]byte{[]byte("hello\n")},
+			expectedFrames: [][]byte{[]byte("hello")},
+		},
+		{
+			name:           "多行连续接收",
+			config:         &DelimiterConfig{Delimiter: []byte("\n"), StripDelimiter: true},
+			inputData:      [][]byte{[]byte("a\nbb\nccc\n")},
+			expectedFrames: [][]byte{[]byte("a"), []byte("bb"), []byte("ccc")},
+		},
+		{
+			name:           "数据分多次接收",
+			config:         &DelimiterConfig{Delimiter: []byte("\n"), StripDelimiter: true},
+			inputData:      [][]byte{[]byte("hel"), []byte("lo"), []byte("\nwor"), []byte("ld\n")},
+			expectedFrames: [][]byte{[]byte("hello"), []byte("world")},
+		},
+		{
+			name:           "多字节分隔符被拆分",
+			config:         &DelimiterConfig{Delimiter: []byte("\r\n"), StripDelimiter: true},
+			inputData:      [][]byte{[]byte("hello\r"), []byte("\nworld\r"), []byte("\n")},
+			expectedFrames: [][]byte{[]byte("hello"), []byte("world")},
+		},
+		{
+			name:           "空帧",
+			config:         &DelimiterConfig{Delimiter: []byte("\n"), StripDelimiter: true},
+			inputData:      [][]byte{[]byte("\n")},
+			expectedFrames: [][]byte{{}},
+		},
+		{
+			name:           "未收到分隔符",
+			config:         &DelimiterConfig{Delimiter: []byte("\n")},
+			inputData:      [][]byte{[]byte("hello")},
+			expectedFrames: nil,
+		},
+		{
+			name:          "没有分隔符超过最大长度",
+			config:        &DelimiterConfig{Delimiter: []byte("\n"), MaxFrameLength: 4},
+			inputData:     [][]byte{[]byte("hel"), []byte("lo")},
+			expectedError: true,
+			errorMessage:  "frame too large",
+		},
+		{
+			name:          "帧超过最大长度",
+			config:        &DelimiterConfig{Delimiter: []byte("\n"), MaxFrameLength: 4},
+			inputData:     [][]byte{[]byte("hello\n")},
+			expectedError: true,
+			errorMessage:  "frame too large",
+		},
+		{
+			name:           "帧恰好等于最大长度",
+			config:         &DelimiterConfig{Delimiter: []byte("\r\n"), MaxFrameLength: 5, StripDelimiter: true},
+			inputData:      [][]byte{[]byte("hello\r"), []byte("\n")},
+			expectedFrames: [][]byte{[]byte("hello")},
+		},
+		{
+			name:          "空分隔符",
+			config:        &DelimiterConfig{},
+			inputData:     [][]byte{[]byte("hello\n")},
+			expectedError: true,
+			errorMessage:  "empty delimiter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := &DelimiterFrame{Dc: tt.config}
+
+			var actualFrames [][]byte
+			var err error
+
+			for _, input := range tt.inputData {
+				for {
+					var frameData []byte
+					frameData, err = frame.ReadFrame(input)
+					if err != nil || frameData == nil {
+						break
+					}
+					actualFrames = append(actualFrames, frameData)
+					input = nil
+				}
+				if err != nil {
+					break
+				}
+			}
+
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("期望出现错误，但没有错误")
+				} else if err.Error() != tt.errorMessage {
+					t.Errorf("错误信息不匹配，期望: %s, 实际: %s", tt.errorMessage, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+			}
+			if len(actualFrames) != len(tt.expectedFrames) {
+				t.Fatalf("帧数量不匹配，期望: %d, 实际: %d", len(tt.expectedFrames), len(actualFrames))
+			}
+			for i := range tt.expectedFrames {
+				if !bytesEqual(actualFrames[i], tt.expectedFrames[i]) {
+					t.Errorf("第 %d 个帧内容不匹配，期望: %q, 实际: %q", i+1, tt.expectedFrames[i], actualFrames[i])
+				}
+			}
+		})
+	}
+}
+
+// TestDelimiterFrame_ReadFrame_Escape 测试转义后的 body 可以包含分隔符和转义字节
+func TestDelimiterFrame_ReadFrame_Escape(t *testing.T) {
+	config := &DelimiterConfig{Delimiter: []byte{0x7E}, StripDelimiter: true, EscapeByte: 0x7D}
+	body := []byte{0x01, 0x7E, 0x02, 0x7D, 0x03}
+
+	encoded, err := config.Encode(body)
+	if err != nil {
+		t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+	}
+	expected := []byte{0x01, 0x7D, 0x7E, 0x02, 0x7D, 0x7D, 0x03, 0x7E}
+	if !bytesEqual(encoded, expected) {
+		t.Fatalf("编码结果不匹配，期望: %v, 实际: %v", expected, encoded)
+	}
+
+	// 逐字节输入，转义字节和被转义的字节分在两次读取中
+	frame := &DelimiterFrame{Dc: config}
+	var result []byte
+	for i, c := range encoded {
+		got, err := frame.ReadFrame([]byte{c})
+		if err != nil {
+			t.Fatalf("第 %d 个字节不期望出现错误: %v", i, err)
+		}
+		if got != nil {
+			if i != len(encoded)-1 {
+				t.Fatalf("在第 %d 个字节提前返回了帧: %v", i, got)
+			}
+			result = got
+		}
+	}
+	if !bytesEqual(result, body) {
+		t.Errorf("期望 %v，实际: %v", body, result)
+	}
+
+	// 保留分隔符，多字节分隔符
+	config = &DelimiterConfig{Delimiter: []byte("\r\n"), EscapeByte: '\\'}
+	encoded, _ = config.Encode([]byte("a\r\nb\\"))
+	frame = &DelimiterFrame{Dc: config}
+	got, err := frame.ReadFrame(append(encoded, 'x'))
+	if err != nil || string(got) != "a\r\nb\\\r\n" {
+		t.Errorf("期望 %q，实际: %q, %v", "a\r\nb\\\r\n", got, err)
+	}
+
+	if _, err := (&DelimiterConfig{Delimiter: []byte("\n")}).Encode([]byte("a\nb")); err == nil {
+		t.Error("未开启转义时 body 包含分隔符应返回错误")
+	}
+}
+
+// TestDelimiterFrame_ReadFrame_NoAlias 测试返回的帧不引用内部缓冲区，append 不会覆盖下一个帧
+func TestDelimiterFrame_ReadFrame_NoAlias(t *testing.T) {
+	for _, dc := range []*DelimiterConfig{
+		{Delimiter: []byte("\n"), StripDelimiter: true},
+		{Delimiter: []byte("\n")},
+		{Delimiter: []byte("\n"), StripDelimiter: true, EscapeByte: '\\'},
+	} {
+		f := &DelimiterFrame{Dc: dc}
+		first, err := f.ReadFrame([]byte("ab\ncd\n"))
+		if err != nil || first == nil {
+			t.Fatalf("期望取出第一个帧，实际: %q, %v", first, err)
+		}
+		_ = append(first, "YZ"...)
+		second, err := f.ReadFrame(nil)
+		if err != nil || string(bytes.TrimSuffix(second, []byte("\n"))) != "cd" {
+			t.Errorf("期望 cd，实际: %q, %v", second, err)
+		}
+	}
+}
+
+// TestDelimiterFrame_ReadFrame_TooLarge 测试超长帧返回 ErrFrameTooLarge
+func TestDelimiterFrame_ReadFrame_TooLarge(t *testing.T) {
+	for _, dc := range []*DelimiterConfig{
+		{Delimiter: []byte("\n"), MaxFrameLength: 3},
+		{Delimiter: []byte("\n"), MaxFrameLength: 3, EscapeByte: '\\'},
+	} {
+		f := &DelimiterFrame{Dc: dc}
+		if _, err := f.ReadFrame([]byte("abcdef\n")); !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("完整的超长帧期望 ErrFrameTooLarge，实际: %v", err)
+		}
+		f = &DelimiterFrame{Dc: dc}
+		if _, err := f.ReadFrame([]byte("abcdef")); !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("没有分隔符的超长数据期望 ErrFrameTooLarge，实际: %v", err)
+		}
+	}
+}
+
+// TestDelimiterFrame_ReadFrame_TooLargeResync 测试超长帧分多次到达时只报告一次、不再缓冲，遇到下一个分隔符后恢复
+func TestDelimiterFrame_ReadFrame_TooLargeResync(t *testing.T) {
+	for _, dc := range []*DelimiterConfig{
+		{Delimiter: []byte("\r\n"), MaxFrameLength: 4, StripDelimiter: true},
+		{Delimiter: []byte("\r\n"), MaxFrameLength: 4, StripDelimiter: true, EscapeByte: '\\'},
+	} {
+		f := &DelimiterFrame{Dc: dc}
+		if _, err := f.ReadFrame([]byte("xxxxxxxx")); !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			if frame, err := f.ReadFrame([]byte("xxxxxxxx")); frame != nil || err != nil {
+				t.Fatalf("期望继续丢弃超长帧，实际: %q, %v", frame, err)
+			}
+		}
+		if len(f.buf) > 8 {
+			t.Fatalf("丢弃期间不应缓冲数据，实际缓冲 %d 字节", len(f.buf))
+		}
+
+		// 分隔符被拆在两次读取之间，之后的帧照常返回
+		if frame, err := f.ReadFrame([]byte("xx\r")); frame != nil || err != nil {
+			t.Fatalf("期望继续丢弃超长帧，实际: %q, %v", frame, err)
+		}
+		frame, err := f.ReadFrame([]byte("\nok\r\n"))
+		if err != nil || string(frame) != "ok" {
+			t.Fatalf("期望 ok，实际: %q, %v", frame, err)
+		}
+
+		// 一次到达的完整超长帧被丢弃到分隔符为止，后面的帧不受影响
+		if _, err := f.ReadFrame([]byte("abcdefgh\r\nnext\r\n")); !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+		}
+		if frame, err := f.ReadFrame(nil); err != nil || string(frame) != "next" {
+			t.Fatalf("期望 next，实际: %q, %v", frame, err)
+		}
+	}
+
+	// 丢弃期间被转义的分隔符不算帧结束
+	f := &DelimiterFrame{Dc: &DelimiterConfig{Delimiter: []byte("\n"), MaxFrameLength: 4, StripDelimiter: true, EscapeByte: '\\'}}
+	if _, err := f.ReadFrame([]byte("xxxxxxx\\")); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if frame, err := f.ReadFrame([]byte("\nyy\nok\n")); err != nil || string(frame) != "ok" {
+		t.Fatalf("期望 ok，实际: %q, %v", frame, err)
+	}
+}
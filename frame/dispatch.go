@@ -0,0 +1,98 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnknownType 帧的类型字段没有注册对应的处理函数
+var ErrUnknownType = errors.New("unknown frame type")
+
+// Dispatcher 从 FrameReader 中读取帧，按头部中的类型字段分发给注册的处理函数
+type Dispatcher struct {
+	fr       *FrameReader
+	field    FieldSpec
+	handlers map[uint64]func(body []byte) error
+}
+
+// NewDispatcher 创建一个从 fr 读取帧的 Dispatcher
+// field 描述类型字段在头部中的位置，与 HeaderConfig.Fields 相同，Offset 从 Magic 之后开始计算，
+// 类型字段可以是 Fields 中的一个字段，也可以位于 LengthFieldOffset 之前的固定字节中。
+// field.ByteOrder 为 nil 时与长度字段使用相同的字节序：HeaderConfig.ByteOrder，或者 Frame.SetByteOrder / AutoByteOrder 锁定的字节序
+func NewDispatcher(fr *FrameReader, field FieldSpec) (*Dispatcher, error) {
+	if err := fr.frame.Hc.validateField(field); err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		fr:       fr,
+		field:    field,
+		handlers: make(map[uint64]func(body []byte) error),
+	}, nil
+}
+
+// Handle 为类型 typ 注册处理函数，重复注册会覆盖之前的处理函数
+// 处理函数收到的 body 与 FrameReader.Next 返回的相同。Handle 需要在 Run / Next 之前调用，不能与它们并发
+func (d *Dispatcher) Handle(typ uint64, handler func(body []byte) error) {
+	d.handlers[typ] = handler
+}
+
+// Next 读取一个帧并调用对应的处理函数，返回处理函数的错误
+// 类型没有注册时返回包装了 ErrUnknownType 的错误，这个帧被丢弃，可以继续调用 Next
+func (d *Dispatcher) Next(ctx context.Context) error {
+	typ, body, err := d.fr.nextField(ctx, d.field)
+	if err != nil {
+		return err
+	}
+
+	handler, ok := d.handlers[typ]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownType, typ)
+	}
+	return handler(body)
+}
+
+// validateField 检查 field 能否从按 hc 解析的帧头部中读取
+func (hc *HeaderConfig) validateField(field FieldSpec) error {
+	if field.Offset < 0 {
+		return errors.New("field offset must not be negative")
+	}
+	switch field.Width {
+	case 1:
+	case 2, 4, 8:
+		if field.ByteOrder == nil && hc.ByteOrder == nil {
+			return errors.New("ByteOrder is required")
+		}
+	default:
+		return errors.New("unsupported field width, only 1, 2, 4 or 8")
+	}
+	return nil
+}
+
+// nextField 读取一个帧，返回 field 在帧头部中的值和 body
+func (fr *FrameReader) nextField(ctx context.Context, field FieldSpec) (uint64, []byte, error) {
+	header, body, err := fr.next(ctx, true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	hc := fr.frame.Hc
+	header = header[len(hc.Magic):]
+	if field.Offset+field.Width > len(header) {
+		return 0, nil, errors.New("field outside header")
+	}
+	return field.value(header, fr.frame.headerByteOrder()), body, nil
+}
+
+// Run 持续读取并分发帧，直到出错或者 ctx 结束；数据流在帧边界正常结束时返回 nil
+func (d *Dispatcher) Run(ctx context.Context) error {
+	for {
+		if err := d.Next(ctx); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestDispatcher 测试按类型字段分发帧
+func TestDispatcher(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder: binary.BigEndian,
+		Fields: []FieldSpec{
+			{Name: "type", Offset: 0, Width: 1},
+			{Name: "length", Offset: 1, Width: 2, Length: true},
+		},
+	}
+	data := []byte{
+		0x01, 0x00, 0x02, 'h', 'i',
+		0x02, 0x00, 0x03, 'f', 'o', 'o',
+		0x01, 0x00, 0x01, '!',
+	}
+
+	d, err := NewDispatcher(NewReader(bytes.NewReader(data), config), FieldSpec{Offset: 0, Width: 1})
+	if err != nil {
+		t.Fatalf("创建 Dispatcher 失败: %v", err)
+	}
+	var got []string
+	d.Handle(1, func(body []byte) error {
+		got = append(got, "1:"+string(body))
+		return nil
+	})
+	d.Handle(2, func(body []byte) error {
+		got = append(got, "2:"+string(body))
+		return nil
+	})
+
+	if err := d.Run(context.Background()); err != nil {
+		t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+	}
+	expected := []string{"1:hi", "2:foo", "1:!"}
+	if len(got) != len(expected) {
+		t.Fatalf("期望 %v，实际: %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("第 %d 个帧期望 %s，实际: %s", i, expected[i], got[i])
+		}
+	}
+}
+
+// TestDispatcher_Errors 测试未注册的类型和处理函数的错误
+func TestDispatcher_Errors(t *testing.T) {
+	// 类型字段位于长度字段之前的固定字节中
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: 1}
+	data := []byte{0x07, 0x00, 0x01, 'a', 0x01, 0x00, 0x01, 'b'}
+
+	d, err := NewDispatcher(NewReader(bytes.NewReader(data), config), FieldSpec{Offset: 0, Width: 1})
+	if err != nil {
+		t.Fatalf("创建 Dispatcher 失败: %v", err)
+	}
+	errHandler := errors.New("handler failed")
+	d.Handle(1, func(body []byte) error { return errHandler })
+
+	if err := d.Next(context.Background()); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("期望 ErrUnknownType，实际: %v", err)
+	}
+	if err := d.Run(context.Background()); err != errHandler {
+		t.Errorf("期望处理函数的错误，实际: %v", err)
+	}
+
+	if _, err := NewDispatcher(NewReader(bytes.NewReader(nil), &HeaderConfig{LengthEncoding: LengthVarint}), FieldSpec{Width: 2}); err == nil {
+		t.Error("缺少字节序应返回错误")
+	}
+}
+
+// TestDispatcher_ByteOrder 测试类型字段与长度字段使用相同的字节序：AutoByteOrder 检测出的或者 SetByteOrder 设置的
+func TestDispatcher_ByteOrder(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:      binary.BigEndian,
+		MaxFrameLength: 16,
+		AutoByteOrder:  true,
+		Fields: []FieldSpec{
+			{Name: "type", Offset: 0, Width: 2},
+			{Name: "length", Offset: 2, Width: 2, Length: true},
+		},
+	}
+	// 对端按小端序发送：type = 0x0102，length = 2
+	data := []byte{0x02, 0x01, 0x02, 0x00, 'h', 'i'}
+
+	fr := NewReader(bytes.NewReader(data), config)
+	d, err := NewDispatcher(fr, FieldSpec{Offset: 0, Width: 2})
+	if err != nil {
+		t.Fatalf("创建 Dispatcher 失败: %v", err)
+	}
+	var got []string
+	d.Handle(0x0102, func(body []byte) error {
+		got = append(got, string(body))
+		return nil
+	})
+	if err := d.Run(context.Background()); err != nil {
+		t.Fatalf("期望按检测出的小端序解析类型字段，实际: %v", err)
+	}
+	if len(got) != 1 || got[0] != "hi" {
+		t.Fatalf("期望 [hi]，实际: %v", got)
+	}
+
+	// 关闭 AutoByteOrder，在帧边界通过 SetByteOrder 切换
+	config.AutoByteOrder = false
+	fr = NewReader(bytes.NewReader(data), config)
+	if err := fr.frame.SetByteOrder(binary.LittleEndian); err != nil {
+		t.Fatal(err)
+	}
+	d, _ = NewDispatcher(fr, FieldSpec{Offset: 0, Width: 2})
+	d.Handle(0x0102, func([]byte) error { return nil })
+	if err := d.Next(context.Background()); err != nil {
+		t.Fatalf("期望按 SetByteOrder 设置的小端序解析类型字段，实际: %v", err)
+	}
+}
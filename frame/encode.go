@@ -0,0 +1,249 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Encode 按配置为 body 加上 Magic 和长度头部（配置了 ChecksumLength 时还会追加校验和），
+// 返回可以直接写入 conn 的完整帧
+// 配置了 LengthFieldOffset 时，body 的前 LengthFieldOffset 个字节写在长度字段之前；
+// 配置了 TrailerLength 时，body 的最后 TrailerLength 个字节作为尾部写在最后；
+// 配置了 HeaderChecksum 时，头部校验和必须紧跟在长度字段之后，由 Encode 计算并写入
+func (hc *HeaderConfig) Encode(body []byte) ([]byte, error) {
+	return hc.EncodeWithOrder(body, nil)
+}
+
+// EncodeWithOrder 与 Encode 相同，但长度字段、头部校验和与校验和按 order 编码，order 为 nil 时使用 hc.ByteOrder
+// 用于代理在同一个 HeaderConfig 上按一种字节序解码、按另一种字节序转发，不需要为编码方向再维护一份配置
+func (hc *HeaderConfig) EncodeWithOrder(body []byte, order binary.ByteOrder) ([]byte, error) {
+	frame, err := hc.AppendFrameWithOrder(make([]byte, 0, hc.maxEncodedLen(len(body))), body, order)
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// EncodeMany 把多个 body 依次编码成首尾相接的帧，写入一次分配的缓冲区，适合一次性刷出一批消息
+// 任何一个 body 编码失败（例如超过长度字段的表示范围）时立即返回，错误中包含出错的下标
+func (hc *HeaderConfig) EncodeMany(bodies [][]byte) ([]byte, error) {
+	size := 0
+	for _, body := range bodies {
+		size += hc.maxEncodedLen(len(body))
+	}
+
+	frames := make([]byte, 0, size)
+	for i, body := range bodies {
+		var err error
+		if frames, err = hc.AppendFrame(frames, body); err != nil {
+			return nil, fmt.Errorf("body %d: %w", i, err)
+		}
+	}
+	return frames, nil
+}
+
+// maxEncodedLen 返回编码 bodyLen 字节的 body 最多需要的字节数
+func (hc *HeaderConfig) maxEncodedLen(bodyLen int) int {
+	n := len(hc.Magic) + bodyLen + hc.ChecksumLength
+	if hc.HeaderChecksum != nil {
+		n += hc.HeaderChecksum.Width
+	}
+	switch hc.LengthEncoding {
+	case LengthVarint:
+		return n + binary.MaxVarintLen64
+	case LengthASCIIDecimal:
+		return n + maxASCIILengthDigits + 1
+	default:
+		return n + max(hc.LengthFieldLength, 0)
+	}
+}
+
+// AppendFrame 与 Encode 相同，但把编码后的帧追加到 dst 并返回扩展后的切片（与 append 的用法相同），
+// 可以把多个帧依次写入一个复用的缓冲区，dst 容量足够时不做任何分配。
+// 出错（例如 body 超过长度字段的表示范围）时返回原来的 dst 和错误，dst 中已有的数据不受影响
+func (hc *HeaderConfig) AppendFrame(dst, body []byte) ([]byte, error) {
+	return hc.AppendFrameWithOrder(dst, body, nil)
+}
+
+// AppendFrameWithOrder 与 AppendFrame 相同，但按 order 编码，order 为 nil 时使用 hc.ByteOrder，见 EncodeWithOrder
+func (hc *HeaderConfig) AppendFrameWithOrder(dst, body []byte, order binary.ByteOrder) ([]byte, error) {
+	if order == nil {
+		order = hc.ByteOrder
+	}
+	if len(hc.Fields) > 0 {
+		return dst, errors.New("Encode does not support Fields")
+	}
+	if hc.LengthFunc != nil {
+		return dst, errors.New("Encode does not support LengthFunc")
+	}
+	if len(body) < hc.LengthFieldOffset {
+		return dst, errors.New("body shorter than LengthFieldOffset")
+	}
+	if len(body)-hc.LengthFieldOffset < hc.TrailerLength {
+		return dst, errors.New("body shorter than TrailerLength")
+	}
+	prefix, body := body[:hc.LengthFieldOffset], body[hc.LengthFieldOffset:]
+	body, trailer := body[:len(body)-hc.TrailerLength], body[len(body)-hc.TrailerLength:]
+
+	// 长度字段的值包含校验和
+	payloadLen := len(body) + hc.ChecksumLength
+	if hc.MaxFrameLength > 0 && payloadLen > hc.MaxFrameLength {
+		return dst, ErrFrameTooLarge
+	}
+	if payloadLen < hc.MinFrameLength {
+		return dst, ErrFrameTooSmall
+	}
+
+	// 头部校验和只支持紧跟在定长长度字段之后，这样它覆盖的就是已经写好的全部头部
+	sumLen := 0
+	if hcs := hc.HeaderChecksum; hcs != nil {
+		if hc.LengthEncoding != LengthFixed || hcs.Offset != hc.LengthFieldOffset+hc.LengthFieldLength {
+			return dst, errors.New("Encode requires HeaderChecksum right after a fixed length field")
+		}
+		sumLen = hcs.Width
+	}
+
+	offset := len(hc.Magic) + len(prefix)
+	start := len(dst)
+	frame := append(dst, hc.Magic...)
+	frame = append(frame, prefix...)
+
+	switch hc.LengthEncoding {
+	case LengthFixed:
+		length, err := hc.encodeLength(payloadLen, offset+hc.LengthFieldLength+sumLen)
+		if err != nil {
+			return dst, err
+		}
+		if hc.LengthMask != 0 && length > hc.LengthMask {
+			return dst, errors.New("body too large for LengthMask")
+		}
+		if length > math.MaxUint64>>hc.LengthShift {
+			// 只有 8 字节长度字段会走到这里，2 / 4 字节在左移之后按字段宽度检查
+			return dst, errors.New("body too large for LengthFieldLength")
+		}
+		length <<= hc.LengthShift
+		switch hc.LengthFieldLength {
+		case 2:
+			if length > 0xFFFF {
+				return dst, errors.New("body too large for LengthFieldLength")
+			}
+		case 4:
+			if length > 0xFFFFFFFF {
+				return dst, errors.New("body too large for LengthFieldLength")
+			}
+		case 8:
+			// 上面已经检查过左移不会溢出，任何值都放得下
+		default:
+			return dst, errors.New("unsupported LengthFieldLength, only 2, 4 or 8")
+		}
+		frame = appendUint(frame, order, hc.LengthFieldLength, length)
+	case LengthVarint:
+		// 长度包含头部时，varint 的字节数又取决于长度本身，反复计算直到编码长度一致
+		n := 1
+		length, err := hc.encodeLength(payloadLen, offset+n)
+		for err == nil && hc.LengthIncludesHeader && uvarintLen(hc.varintWire(length)) != n {
+			n = uvarintLen(hc.varintWire(length))
+			length, err = hc.encodeLength(payloadLen, offset+n)
+		}
+		if err != nil {
+			return dst, err
+		}
+		frame = binary.AppendUvarint(frame, hc.varintWire(length))
+	case LengthASCIIDecimal:
+		// 与 varint 相同，长度包含头部时数字的位数取决于长度本身
+		n := 2
+		length, err := hc.encodeLength(payloadLen, offset+n)
+		for err == nil && hc.LengthIncludesHeader && decimalLen(length)+1 != n {
+			n = decimalLen(length) + 1
+			length, err = hc.encodeLength(payloadLen, offset+n)
+		}
+		if err != nil {
+			return dst, err
+		}
+		frame = strconv.AppendUint(frame, length, 10)
+		frame = append(frame, hc.terminator())
+	default:
+		return dst, errors.New("unsupported LengthEncoding")
+	}
+
+	if sumLen > 0 {
+		frame = appendUint(frame, order, sumLen, uint64(hc.HeaderChecksum.sum(frame[start:])))
+	}
+	frame = append(frame, body...)
+
+	if hc.ChecksumLength > 0 {
+		sum, err := hc.checksum(body)
+		if err != nil {
+			return dst, err
+		}
+		frame = appendUint(frame, order, hc.ChecksumLength, uint64(sum))
+	}
+	frame = append(frame, trailer...)
+
+	return frame, nil
+}
+
+// encodeLength 计算写入长度字段的值，是 adjustLength 的逆运算
+func (hc *HeaderConfig) encodeLength(payloadLen, headerLen int) (uint64, error) {
+	adjustment, err := hc.lengthAdjustment(headerLen)
+	if err != nil {
+		return 0, err
+	}
+	length := int64(payloadLen) - int64(adjustment)
+	if length < 0 {
+		return 0, errors.New("negative length after adjustment")
+	}
+	unit := int64(hc.lengthUnit())
+	if length%unit != 0 {
+		return 0, fmt.Errorf("length %d is not a multiple of LengthUnitBytes %d", length, unit)
+	}
+	return uint64(length / unit), nil
+}
+
+// varintWire 返回长度 length 按 VarintFlavor 写入线路的无符号值，zigzag 编码下非负数 v 编码为 2v
+func (hc *HeaderConfig) varintWire(length uint64) uint64 {
+	if hc.VarintFlavor == VarintZigzag {
+		return length << 1
+	}
+	return length
+}
+
+// uvarintLen 返回 v 编码为 uvarint 后的字节数
+func uvarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// decimalLen 返回 v 的十进制位数
+func decimalLen(v uint64) int {
+	n := 1
+	for v >= 10 {
+		v /= 10
+		n++
+	}
+	return n
+}
+
+// appendUint 把 v 按 order 编码为 width 字节追加到 dst
+func appendUint(dst []byte, order binary.ByteOrder, width int, v uint64) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, width)...)
+	switch width {
+	case 1:
+		dst[n] = byte(v)
+	case 2:
+		order.PutUint16(dst[n:], uint16(v))
+	case 4:
+		order.PutUint32(dst[n:], uint32(v))
+	case 8:
+		order.PutUint64(dst[n:], v)
+	}
+	return dst
+}
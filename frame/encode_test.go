@@ -0,0 +1,415 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+// TestHeaderConfig_Encode 测试编码后能被 ReadFrame 原样解析
+func TestHeaderConfig_Encode(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *HeaderConfig
+		body     []byte
+		expected []byte
+	}{
+		{
+			name:     "2字节长度字段-大端序",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2},
+			body:     []byte("hello"),
+			expected: []byte{0x00, 0x05, 'h', 'e', 'l', 'l', 'o'},
+		},
+		{
+			name:     "4字节长度字段-小端序",
+			config:   &HeaderConfig{ByteOrder: binary.LittleEndian, LengthFieldLength: 4},
+			body:     []byte("hi"),
+			expected: []byte{0x02, 0x00, 0x00, 0x00, 'h', 'i'},
+		},
+		{
+			name:     "varint长度字段",
+			config:   &HeaderConfig{LengthEncoding: LengthVarint},
+			body:     []byte("hi"),
+			expected: []byte{0x02, 'h', 'i'},
+		},
+		{
+			name:     "空body",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2},
+			body:     []byte{},
+			expected: []byte{0x00, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.config.Encode(tt.body)
+			if err != nil {
+				t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+			}
+			if !bytesEqual(encoded, tt.expected) {
+				t.Errorf("编码结果不匹配，期望: %v, 实际: %v", tt.expected, encoded)
+			}
+
+			frame := &Frame{Hc: tt.config}
+			result, err := frame.ReadFrame(encoded)
+			if err != nil {
+				t.Fatalf("解析编码结果时出现错误: %v", err)
+			}
+			if !bytesEqual(result, tt.body) {
+				t.Errorf("往返结果不匹配，期望: %v, 实际: %v", tt.body, result)
+			}
+		})
+	}
+}
+
+// TestHeaderConfig_Encode_Errors 测试编码异常情况
+func TestHeaderConfig_Encode_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       *HeaderConfig
+		body         []byte
+		errorMessage string
+	}{
+		{
+			name:         "body超过2字节长度字段",
+			config:       &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2},
+			body:         make([]byte, 0x10000),
+			errorMessage: "body too large for LengthFieldLength",
+		},
+		{
+			name:         "8字节长度字段左移后溢出",
+			config:       &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 8, LengthShift: 62},
+			body:         []byte("four"),
+			errorMessage: "body too large for LengthFieldLength",
+		},
+		{
+			name:         "不支持的长度字段长度",
+			config:       &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 3},
+			body:         []byte("hi"),
+			errorMessage: "unsupported LengthFieldLength, only 2, 4 or 8",
+		},
+		{
+			name:         "长度字段长度为0",
+			config:       &HeaderConfig{ByteOrder: binary.BigEndian},
+			body:         []byte("hi"),
+			errorMessage: "unsupported LengthFieldLength, only 2, 4 or 8",
+		},
+		{
+			name:         "body短于命令块",
+			config:       &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: 3},
+			body:         []byte("hi"),
+			errorMessage: "body shorter than LengthFieldOffset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.Encode(tt.body)
+			if err == nil {
+				t.Fatalf("期望出现错误，但没有错误")
+			}
+			if err.Error() != tt.errorMessage {
+				t.Errorf("错误信息不匹配，期望: %s, 实际: %s", tt.errorMessage, err.Error())
+			}
+		})
+	}
+}
+
+// TestHeaderConfig_Encode_LengthFieldLength 测试 Encode 和 Parse 接受的长度字段长度一致
+func TestHeaderConfig_Encode_LengthFieldLength(t *testing.T) {
+	for width := -1; width <= 8; width++ {
+		config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: width}
+		encoded, encodeErr := config.Encode([]byte("hi"))
+		_, parseErr := config.Parse(make([]byte, 8))
+		if (encodeErr == nil) != (parseErr == nil) {
+			t.Errorf("LengthFieldLength %d: Encode 错误 %v，Parse 错误 %v", width, encodeErr, parseErr)
+			continue
+		}
+		if encodeErr != nil {
+			if encodeErr.Error() != parseErr.Error() {
+				t.Errorf("LengthFieldLength %d: 错误信息不一致: %v / %v", width, encodeErr, parseErr)
+			}
+			continue
+		}
+		if length, err := config.Parse(encoded); err != nil || length != 2 {
+			t.Errorf("LengthFieldLength %d: 往返结果不正确: %d, %v", width, length, err)
+		}
+	}
+}
+
+// TestHeaderConfig_Encode_LengthFieldOffset 测试命令块写在长度字段之前且不计入长度
+func TestHeaderConfig_Encode_LengthFieldOffset(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: 3, Magic: []byte{0xAB}}
+	encoded, err := config.Encode([]byte("CMDhi"))
+	if err != nil {
+		t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+	}
+	expected := []byte{0xAB, 'C', 'M', 'D', 0x00, 0x02, 'h', 'i'}
+	if !bytesEqual(encoded, expected) {
+		t.Errorf("编码结果不匹配，期望: %v, 实际: %v", expected, encoded)
+	}
+
+	frame := &Frame{Hc: config}
+	body, err := frame.ReadFrame(encoded)
+	if err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+}
+
+// TestHeaderConfig_AppendFrame 测试把帧追加到调用方的缓冲区
+func TestHeaderConfig_AppendFrame(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	buf := make([]byte, 0, 64)
+
+	buf, err := config.AppendFrame(buf, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err = config.AppendFrame(buf, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0x00, 0x02, 'h', 'i', 0x00, 0x03, 'f', 'o', 'o'}
+	if !bytesEqual(buf, expected) {
+		t.Errorf("期望 %v，实际: %v", expected, buf)
+	}
+
+	// 出错时返回原来的 dst
+	got, err := config.AppendFrame(buf, make([]byte, 0x10000))
+	if err == nil || !bytesEqual(got, expected) {
+		t.Errorf("body 过大时应返回错误和原来的 dst，实际: %v, %v", got, err)
+	}
+
+	// 容量足够时不做任何分配
+	body := []byte("hello")
+	allocs := testing.AllocsPerRun(100, func() { _, _ = config.AppendFrame(buf[:0], body) })
+	if allocs != 0 {
+		t.Errorf("期望零分配，实际: %v", allocs)
+	}
+}
+
+// TestHeaderConfig_EncodeWithOrder 测试按指定的字节序编码长度字段和校验和，nil 时使用配置的字节序
+func TestHeaderConfig_EncodeWithOrder(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 4,
+		ChecksumLength:    2,
+		ChecksumFunc:      func([]byte) uint32 { return 0x1234 },
+	}
+
+	little, err := config.EncodeWithOrder([]byte("hi"), binary.LittleEndian)
+	if err != nil || !bytesEqual(little, []byte{0x04, 0, 0, 0, 'h', 'i', 0x34, 0x12}) {
+		t.Fatalf("期望小端编码，实际: %x, %v", little, err)
+	}
+	big, err := config.EncodeWithOrder([]byte("hi"), nil)
+	if err != nil || !bytesEqual(big, []byte{0, 0, 0, 0x04, 'h', 'i', 0x12, 0x34}) {
+		t.Fatalf("order 为 nil 时期望按 ByteOrder 编码，实际: %x, %v", big, err)
+	}
+
+	dst, err := config.AppendFrameWithOrder([]byte{0xFF}, []byte("hi"), binary.LittleEndian)
+	if err != nil || !bytesEqual(dst, append([]byte{0xFF}, little...)) {
+		t.Errorf("AppendFrameWithOrder 结果不匹配，实际: %x, %v", dst, err)
+	}
+}
+
+// TestHeaderConfig_EncodeMany 测试批量编码
+func TestHeaderConfig_EncodeMany(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	encoded, err := config.EncodeMany([][]byte{[]byte("hi"), {}, []byte("foo")})
+	if err != nil {
+		t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+	}
+	expected := []byte{0x00, 0x02, 'h', 'i', 0x00, 0x00, 0x00, 0x03, 'f', 'o', 'o'}
+	if !bytesEqual(encoded, expected) {
+		t.Errorf("编码结果不匹配，期望: %v, 实际: %v", expected, encoded)
+	}
+
+	bodies := [][]byte{[]byte("hi"), []byte("foo"), make([]byte, 1000)}
+	if allocs := testing.AllocsPerRun(10, func() { _, _ = config.EncodeMany(bodies) }); allocs != 1 {
+		t.Errorf("期望只分配一次，实际: %v", allocs)
+	}
+
+	_, err = config.EncodeMany([][]byte{[]byte("hi"), make([]byte, 0x10000)})
+	if err == nil || err.Error() != "body 1: body too large for LengthFieldLength" {
+		t.Errorf("期望第 1 个 body 编码失败，实际: %v", err)
+	}
+
+	config.MaxFrameLength = 2
+	if _, err := config.EncodeMany([][]byte{[]byte("foo")}); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+}
+
+// TestFrame_ReadFrame_Checksum 测试帧尾校验和
+func TestFrame_ReadFrame_Checksum(t *testing.T) {
+	newConfig := func(length int) *HeaderConfig {
+		return &HeaderConfig{
+			ByteOrder:         binary.BigEndian,
+			LengthFieldLength: 2,
+			ChecksumLength:    length,
+			ChecksumFunc:      crc32.ChecksumIEEE,
+		}
+	}
+
+	for _, length := range []int{1, 2, 4} {
+		config := newConfig(length)
+		encoded, err := config.Encode([]byte("hello"))
+		if err != nil {
+			t.Fatalf("编码时出现错误: %v", err)
+		}
+		if len(encoded) != 2+5+length {
+			t.Errorf("编码长度不匹配，期望: %d, 实际: %d", 2+5+length, len(encoded))
+		}
+
+		frame := &Frame{Hc: config}
+		result, err := frame.ReadFrame(encoded)
+		if err != nil {
+			t.Fatalf("校验和长度 %d 解析时出现错误: %v", length, err)
+		}
+		if string(result) != "hello" {
+			t.Errorf("校验和长度 %d 包内容不匹配，实际: %q", length, result)
+		}
+	}
+
+	t.Run("校验和不匹配", func(t *testing.T) {
+		config := newConfig(4)
+		encoded, _ := config.Encode([]byte("hello"))
+		encoded[3] ^= 0xFF // 破坏 body
+		next, _ := config.Encode([]byte("world"))
+
+		frame := &Frame{Hc: config}
+		_, err := frame.ReadFrame(append(encoded, next...))
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("期望 ErrChecksumMismatch，实际: %v", err)
+		}
+		var ce *ChecksumError
+		if !errors.As(err, &ce) {
+			t.Fatalf("期望 *ChecksumError，实际: %T", err)
+		}
+		if ce.Actual != crc32.ChecksumIEEE([]byte("hello")) {
+			t.Errorf("实际校验和不匹配: %#x", ce.Actual)
+		}
+		if ce.Expected == ce.Actual {
+			t.Errorf("期望值与实际值不应相同")
+		}
+
+		// 损坏的帧被丢弃，后续帧可以正常读取
+		result, err := frame.ReadFrame(nil)
+		if err != nil || string(result) != "world" {
+			t.Errorf("期望读到下一个帧 world，实际: %q, %v", result, err)
+		}
+	})
+
+	t.Run("帧比校验和还短", func(t *testing.T) {
+		frame := &Frame{Hc: newConfig(4)}
+		_, err := frame.ReadFrame([]byte{0x00, 0x02, 0x01, 0x02})
+		if err == nil || err.Error() != "frame shorter than checksum" {
+			t.Errorf("期望 frame shorter than checksum 错误，实际: %v", err)
+		}
+	})
+}
+
+// TestFrame_ReadFrame_LengthIncludesHeader 测试长度字段包含头部本身的情况
+func TestFrame_ReadFrame_LengthIncludesHeader(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *HeaderConfig
+		input         []byte
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "2字节长度包含头部",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthIncludesHeader: true},
+			input:    []byte{0x00, 0x07, 'h', 'e', 'l', 'l', 'o'}, // 2 + 5
+			expected: "hello",
+		},
+		{
+			name:     "4字节长度包含头部",
+			config:   &HeaderConfig{ByteOrder: binary.LittleEndian, LengthFieldLength: 4, LengthIncludesHeader: true},
+			input:    []byte{0x06, 0x00, 0x00, 0x00, 'h', 'i'}, // 4 + 2
+			expected: "hi",
+		},
+		{
+			name:     "长度包含Magic和长度字段",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthIncludesHeader: true, Magic: []byte{0xAB}},
+			input:    []byte{0xAB, 0x00, 0x05, 'h', 'i'}, // 1 + 2 + 2
+			expected: "hi",
+		},
+		{
+			name:     "只有头部的空帧",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthIncludesHeader: true},
+			input:    []byte{0x00, 0x02},
+			expected: "",
+		},
+		{
+			name:     "LengthAdjustment为正",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthAdjustment: 2},
+			input:    []byte{0x00, 0x01, 'a', 'b', 'c'},
+			expected: "abc",
+		},
+		{
+			name:     "LengthAdjustment为负",
+			config:   &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthAdjustment: -2},
+			input:    []byte{0x00, 0x05, 'a', 'b', 'c'},
+			expected: "abc",
+		},
+		{
+			name:          "长度小于头部",
+			config:        &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthIncludesHeader: true},
+			input:         []byte{0x00, 0x01},
+			expectedError: true,
+		},
+		{
+			name:          "同时设置两种修正",
+			config:        &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthIncludesHeader: true, LengthAdjustment: -2},
+			input:         []byte{0x00, 0x07, 'h', 'e', 'l', 'l', 'o'},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := &Frame{Hc: tt.config}
+			result, err := frame.ReadFrame(tt.input)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("期望出现错误，但没有错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+			}
+			if result == nil || string(result) != tt.expected {
+				t.Errorf("包内容不匹配，期望: %q, 实际: %q", tt.expected, result)
+			}
+
+			// 编码结果应与输入一致
+			encoded, err := tt.config.Encode([]byte(tt.expected))
+			if err != nil {
+				t.Fatalf("编码时出现错误: %v", err)
+			}
+			if !bytesEqual(encoded, tt.input) {
+				t.Errorf("编码结果不匹配，期望: %v, 实际: %v", tt.input, encoded)
+			}
+		})
+	}
+
+	t.Run("varint长度包含头部", func(t *testing.T) {
+		config := &HeaderConfig{LengthEncoding: LengthVarint, LengthIncludesHeader: true}
+		for _, size := range []int{0, 126, 127, 200, 16382, 16383, 20000} {
+			body := make([]byte, size)
+			encoded, err := config.Encode(body)
+			if err != nil {
+				t.Fatalf("body 长度 %d 编码时出现错误: %v", size, err)
+			}
+			frame := &Frame{Hc: config}
+			result, err := frame.ReadFrame(encoded)
+			if err != nil || len(result) != size {
+				t.Errorf("body 长度 %d 往返失败: %d, %v", size, len(result), err)
+			}
+		}
+	})
+}
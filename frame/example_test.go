@@ -0,0 +1,31 @@
+package frame_test
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go-toolkit/frame"
+)
+
+// 包外使用 NewFrame 创建 Frame，不需要访问未导出的缓冲区
+func ExampleNewFrame() {
+	f, err := frame.NewFrame(&frame.HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// 一个包被拆成两次到达
+	for _, raw := range [][]byte{{0x00, 0x05, 'h', 'e'}, {'l', 'l', 'o'}} {
+		body, err := f.ReadFrame(raw)
+		if err != nil {
+			panic(err)
+		}
+		if body != nil {
+			fmt.Println(string(body))
+		}
+	}
+	// Output: hello
+}
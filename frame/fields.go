@@ -0,0 +1,141 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// FieldSpec 定长头部中的一个字段，见 HeaderConfig.Fields
+type FieldSpec struct {
+	Name      string
+	Offset    int              // 字段相对 Magic 之后的偏移
+	Width     int              // 字段占用字节数（1、2、4 或 8）
+	ByteOrder binary.ByteOrder // 字段的字节序，nil 表示使用 HeaderConfig.ByteOrder
+	Length    bool             // 是否为长度字段，Fields 中必须有且只有一个
+}
+
+// fieldsLen 返回 Fields 描述的定长头部（不含 Magic）的长度
+func (hc *HeaderConfig) fieldsLen() int {
+	n := 0
+	for _, field := range hc.Fields {
+		n = max(n, field.Offset+field.Width)
+	}
+	return n
+}
+
+// validateFields 检查 Fields 是否合法
+func (hc *HeaderConfig) validateFields() error {
+	names := make(map[string]bool, len(hc.Fields))
+	lengths := 0
+	for _, field := range hc.Fields {
+		if field.Name == "" {
+			return errors.New("field name is required")
+		}
+		if names[field.Name] {
+			return errors.New("duplicate field name " + field.Name)
+		}
+		names[field.Name] = true
+
+		if field.Offset < 0 {
+			return errors.New("field offset must not be negative")
+		}
+		switch field.Width {
+		case 1:
+		case 2, 4, 8:
+			if field.ByteOrder == nil && hc.ByteOrder == nil {
+				return errors.New("ByteOrder is required")
+			}
+		default:
+			return errors.New("unsupported field width, only 1, 2, 4 or 8")
+		}
+		if field.Length {
+			lengths++
+			if err := hc.validateLengthMask(field.Width); err != nil {
+				return err
+			}
+		}
+	}
+	if lengths != 1 {
+		return errors.New("exactly one field must be the length field")
+	}
+	return nil
+}
+
+// value 按 field 从 header（Magic 之后的部分）中读取字段的值，order 为默认字节序
+func (field FieldSpec) value(header []byte, order binary.ByteOrder) uint64 {
+	if field.ByteOrder != nil {
+		order = field.ByteOrder
+	}
+	b := header[field.Offset : field.Offset+field.Width]
+	switch field.Width {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(order.Uint16(b))
+	case 4:
+		return uint64(order.Uint32(b))
+	default:
+		return order.Uint64(b)
+	}
+}
+
+// ParseHeader 按 Fields 解析帧头部中的所有字段，返回字段名到值的映射
+// header 从帧的开头算起（包含 Magic），例如 InitialBytesToStrip 为 StripNone 时 ReadFrame 返回的完整帧
+func (hc *HeaderConfig) ParseHeader(header []byte) (map[string]uint64, error) {
+	if len(hc.Fields) == 0 {
+		return nil, errors.New("no Fields configured")
+	}
+	return hc.parseFields(header, hc.ByteOrder)
+}
+
+// parseFields 与 ParseHeader 相同，但使用指定的默认字节序
+func (hc *HeaderConfig) parseFields(header []byte, order binary.ByteOrder) (map[string]uint64, error) {
+	if len(header) < len(hc.Magic)+hc.fieldsLen() {
+		return nil, errors.New("header too short")
+	}
+	if !bytes.HasPrefix(header, hc.Magic) {
+		return nil, ErrBadMagic
+	}
+	header = header[len(hc.Magic):]
+
+	fields := make(map[string]uint64, len(hc.Fields))
+	for _, field := range hc.Fields {
+		fields[field.Name] = field.value(header, order)
+	}
+	return fields, nil
+}
+
+// parseFieldsLength 从 buf（Magic 之后的部分）中读取长度字段，返回长度值和定长头部的长度
+func (hc *HeaderConfig) parseFieldsLength(buf []byte, order binary.ByteOrder) (value uint64, n int, ok bool) {
+	n = hc.fieldsLen()
+	if len(buf) < n {
+		return 0, 0, false
+	}
+	for _, field := range hc.Fields {
+		if field.Length {
+			value = hc.maskLength(field.value(buf, order))
+			break
+		}
+	}
+	return value, n, true
+}
+
+// ReadFrameWithHeader 与 ReadFrame 相同，同时返回按 Fields 解析出的头部字段，未配置 Fields 时返回错误
+// 数据不足时返回 (nil, nil, nil)
+func (f *Frame) ReadFrameWithHeader(raw []byte) (map[string]uint64, []byte, error) {
+	if len(f.Hc.Fields) == 0 {
+		return nil, nil, errors.New("no Fields configured")
+	}
+
+	f.lock.Lock()
+	var fields map[string]uint64
+	rf, body, err := f.readFrameRaw(raw)
+	if body != nil {
+		fields, err = f.Hc.parseFields(rf.header, f.byteOrder())
+	}
+	f.lock.Unlock()
+
+	f.notify(body, err)
+	return fields, body, err
+}
@@ -0,0 +1,116 @@
+package frame
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestFrame_ReadFrameWithHeader 测试多字段定长头部
+func TestFrame_ReadFrameWithHeader(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder: binary.BigEndian,
+		Magic:     []byte{0xAB},
+		Fields: []FieldSpec{
+			{Name: "version", Offset: 0, Width: 1},
+			{Name: "type", Offset: 1, Width: 1},
+			{Name: "flags", Offset: 2, Width: 2},
+			{Name: "length", Offset: 4, Width: 4, Length: true},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("配置应合法，实际: %v", err)
+	}
+
+	data := []byte{0xAB, 0x01, 0x07, 0x80, 0x01, 0x00, 0x00, 0x00, 0x02, 'h', 'i'}
+	frame := &Frame{Hc: config}
+
+	fields, body, err := frame.ReadFrameWithHeader(data[:6])
+	if fields != nil || body != nil || err != nil {
+		t.Fatalf("数据不足应返回 (nil, nil, nil)，实际: %v, %q, %v", fields, body, err)
+	}
+	fields, body, err = frame.ReadFrameWithHeader(data[6:])
+	if err != nil || string(body) != "hi" {
+		t.Fatalf("期望 hi，实际: %q, %v", body, err)
+	}
+	expected := map[string]uint64{"version": 1, "type": 7, "flags": 0x8001, "length": 2}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Errorf("字段 %s 期望 %#x，实际: %#x", name, value, fields[name])
+		}
+	}
+
+	// ParseHeader 可以直接解析包含 Magic 的完整帧
+	parsed, err := config.ParseHeader(data)
+	if err != nil || parsed["flags"] != 0x8001 {
+		t.Errorf("ParseHeader 结果不正确: %v, %v", parsed, err)
+	}
+	if _, err := config.ParseHeader(data[:4]); err == nil {
+		t.Error("头部不完整应返回错误")
+	}
+
+	// 普通的 ReadFrame 同样按长度字段切分
+	frame = &Frame{Hc: config}
+	if body, err := frame.ReadFrame(data); err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+}
+
+// TestHeaderConfig_Validate_Fields 测试 Fields 配置校验
+func TestHeaderConfig_Validate_Fields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []FieldSpec
+		order  binary.ByteOrder
+	}{
+		{name: "没有长度字段", fields: []FieldSpec{{Name: "type", Width: 1}}},
+		{name: "多个长度字段", fields: []FieldSpec{{Name: "a", Width: 1, Length: true}, {Name: "b", Width: 1, Length: true}}},
+		{name: "重复的字段名", fields: []FieldSpec{{Name: "a", Width: 1, Length: true}, {Name: "a", Offset: 1, Width: 1}}},
+		{name: "不支持的宽度", fields: []FieldSpec{{Name: "a", Width: 3, Length: true}}, order: binary.BigEndian},
+		{name: "缺少字节序", fields: []FieldSpec{{Name: "a", Width: 2, Length: true}}},
+		{name: "负数偏移", fields: []FieldSpec{{Name: "a", Offset: -1, Width: 1, Length: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &HeaderConfig{ByteOrder: tt.order, Fields: tt.fields}
+			if err := config.Validate(); err == nil {
+				t.Error("期望校验失败，但没有错误")
+			}
+		})
+	}
+}
+
+// TestFrame_ReadFrameWithHeader_MixedEndian 测试字段各自的字节序：类型字段按默认的大端序，长度字段按小端序
+func TestFrame_ReadFrameWithHeader_MixedEndian(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder: binary.BigEndian,
+		Fields: []FieldSpec{
+			{Name: "type", Offset: 0, Width: 2},
+			{Name: "length", Offset: 2, Width: 4, ByteOrder: binary.LittleEndian, Length: true},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("配置应合法，实际: %v", err)
+	}
+
+	// type = 0x0102（大端序），length = 3（小端序）
+	data := []byte{0x01, 0x02, 0x03, 0x00, 0x00, 0x00, 'a', 'b', 'c'}
+	frame := &Frame{Hc: config}
+	fields, body, err := frame.ReadFrameWithHeader(data)
+	if err != nil || string(body) != "abc" {
+		t.Fatalf("期望 abc，实际: %q, %v", body, err)
+	}
+	if fields["type"] != 0x0102 || fields["length"] != 3 {
+		t.Errorf("期望 type=0x102 length=3，实际: %v", fields)
+	}
+
+	// 没有默认字节序时，多字节字段必须各自指定
+	config.ByteOrder = nil
+	if err := config.Validate(); err == nil || err.Error() != "ByteOrder is required" {
+		t.Errorf("期望 type 字段缺少字节序时报错，实际: %v", err)
+	}
+	config.Fields[0].ByteOrder = binary.BigEndian
+	if err := config.Validate(); err != nil {
+		t.Errorf("所有多字节字段都指定了字节序时配置应合法，实际: %v", err)
+	}
+}
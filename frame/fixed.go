@@ -0,0 +1,42 @@
+package frame
+
+import (
+	"errors"
+	"sync"
+)
+
+// FixedFrame 把数据流切分为固定长度的记录，适用于没有长度前缀的定长协议
+type FixedFrame struct {
+	size int
+	buf  []byte
+	lock sync.Mutex
+}
+
+// NewFixedFrame 创建一个每帧 size 字节的 FixedFrame，size 必须大于 0
+func NewFixedFrame(size int) (*FixedFrame, error) {
+	if size <= 0 {
+		return nil, errors.New("fixed frame size must be positive")
+	}
+	return &FixedFrame{size: size}, nil
+}
+
+// ReadFrame 输入一次读到的数据，输出一个 size 字节的完整帧
+// - 如果数据不足，返回 (nil, nil)，等待下次补充
+// - 如果有多个帧，调用方需要多次调用 ReadFrame 才能依次取出
+func (f *FixedFrame) ReadFrame(raw []byte) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.buf = append(f.buf, raw...)
+	if len(f.buf) < f.size {
+		return nil, nil
+	}
+
+	frame := make([]byte, f.size)
+	copy(frame, f.buf)
+
+	// 更新缓冲区，丢掉已消费的部分
+	f.buf = f.buf[f.size:]
+
+	return frame, nil
+}
@@ -0,0 +1,90 @@
+package frame
+
+import (
+	"testing"
+)
+
+// TestNewFixedFrame 测试定长帧的构造参数校验
+func TestNewFixedFrame(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if _, err := NewFixedFrame(size); err == nil {
+			t.Errorf("size 为 %d 时期望出现错误", size)
+		}
+	}
+	if _, err := NewFixedFrame(1); err != nil {
+		t.Errorf("不期望出现错误，但出现了错误: %v", err)
+	}
+}
+
+// TestFixedFrame_ReadFrame 测试定长帧切分
+func TestFixedFrame_ReadFrame(t *testing.T) {
+	tests := []struct {
+		name           string
+		size           int
+		inputData      [][]byte
+		expectedFrames []string
+		remaining      int
+	}{
+		{
+			name:           "恰好一帧",
+			size:           4,
+			inputData:      [][]byte{[]byte("abcd")},
+			expectedFrames: []string{"abcd"},
+		},
+		{
+			name:           "多帧连续接收",
+			size:           2,
+			inputData:      [][]byte{[]byte("aabbcc")},
+			expectedFrames: []string{"aa", "bb", "cc"},
+		},
+		{
+			name:           "分多次接收并保留余数",
+			size:           3,
+			inputData:      [][]byte{[]byte("a"), []byte("bcd"), []byte("efgh")},
+			expectedFrames: []string{"abc", "def"},
+			remaining:      2,
+		},
+		{
+			name:      "数据不足",
+			size:      8,
+			inputData: [][]byte{[]byte("abc")},
+			remaining: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := NewFixedFrame(tt.size)
+			if err != nil {
+				t.Fatalf("创建 FixedFrame 失败: %v", err)
+			}
+
+			var frames []string
+			for _, input := range tt.inputData {
+				for {
+					body, err := frame.ReadFrame(input)
+					if err != nil {
+						t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+					}
+					if body == nil {
+						break
+					}
+					frames = append(frames, string(body))
+					input = nil
+				}
+			}
+
+			if len(frames) != len(tt.expectedFrames) {
+				t.Fatalf("帧数量不匹配，期望: %v, 实际: %v", tt.expectedFrames, frames)
+			}
+			for i := range frames {
+				if frames[i] != tt.expectedFrames[i] {
+					t.Errorf("第 %d 个帧不匹配，期望: %q, 实际: %q", i+1, tt.expectedFrames[i], frames[i])
+				}
+			}
+			if len(frame.buf) != tt.remaining {
+				t.Errorf("剩余数据长度不匹配，期望: %d, 实际: %d", tt.remaining, len(frame.buf))
+			}
+		})
+	}
+}
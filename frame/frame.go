@@ -1,74 +1,1858 @@
 package frame
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
 	"sync"
+	"time"
 )
 
+// ErrBadMagic 帧开头的字节与 HeaderConfig.Magic 不一致，通常说明对端不是本协议的流量
+var ErrBadMagic = errors.New("bad magic")
+
+// ErrNegativeLength 头部解析成功但算出的 body 长度为负数：有符号的长度字段（VarintZigzag）解码出负数、
+// LengthFunc 返回负数，或者按 LengthAdjustment / LengthIncludesHeader 修正之后小于 0。
+// 与 ErrFrameTooLarge 一样，通常说明配置与对端不一致或者收到了恶意数据，而不是数据还没有到齐
+var ErrNegativeLength = errors.New("negative length")
+
+// ErrHighWaterMark 缓冲区中的数据达到 HeaderConfig.HighWaterMark 但仍然凑不出一个完整帧，继续读取也无法取得进展
+var ErrHighWaterMark = errors.New("buffer reached HighWaterMark without a complete frame")
+
+// ErrRateLimited 取出的帧超过了 HeaderConfig.RateLimiter 允许的速率
+var ErrRateLimited = errors.New("frame rate limited")
+
+// RateLimiter 帧速率限制器，见 HeaderConfig.RateLimiter。golang.org/x/time/rate 的 *rate.Limiter 满足这个接口
+type RateLimiter interface {
+	// Allow 在允许再取出一个帧时返回 true 并消耗一个令牌
+	Allow() bool
+}
+
+// ErrFrameTooLarge 帧的 body 长度超过 HeaderConfig.MaxFrameLength（或 Frame.SetMaxFrameLength 设置的上限）
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// ErrFrameTooSmall 帧的 body 长度小于 HeaderConfig.MinFrameLength
+var ErrFrameTooSmall = errors.New("frame too small")
+
+// ErrImplausibleLength HeaderConfig.PlausibilityCheck 认为解析出的长度不合理，通常说明数据流已经错位
+var ErrImplausibleLength = errors.New("implausible frame length")
+
+// ErrConfigChangedMidFrame 缓冲区中还有未完成的帧时，影响头部解析的配置被修改了
+var ErrConfigChangedMidFrame = errors.New("header config changed mid-frame")
+
+// ErrLengthOverflow 长度字段的值（或加上修正量之后）超出了 int 的表示范围
+var ErrLengthOverflow = errors.New("length overflow")
+
+// ErrChecksumMismatch 帧校验和不匹配，具体的期望值/实际值见 *ChecksumError
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumError 校验和不匹配时返回，可通过 errors.Is(err, ErrChecksumMismatch) 判断
+type ChecksumError struct {
+	Expected uint32 // 根据 body 计算出的校验和
+	Actual   uint32 // 帧尾携带的校验和
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %#x, actual %#x", e.Expected, e.Actual)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// ShortBufferError ReadFrameInto 的 dst 放不下 body 时返回，可通过 errors.Is(err, io.ErrShortBuffer) 判断
+// 这个帧仍然保留在缓冲区中，按 Need 分配 dst 之后再次调用即可取出
+type ShortBufferError struct {
+	Need int // body 的长度
+	Have int // dst 的长度
+}
+
+func (e *ShortBufferError) Error() string {
+	return fmt.Sprintf("short buffer: frame has %d bytes, dst has %d", e.Need, e.Have)
+}
+
+func (e *ShortBufferError) Unwrap() error {
+	return io.ErrShortBuffer
+}
+
+// Frame 是单个字节流的解码器，缓冲区保存的是这一条流中尚未凑成完整帧的数据
+//
+// 内部的锁只保证方法调用本身不会发生数据竞争，并不能把多条流的数据分开：
+// 多个连接共用一个 Frame 时，各自的字节会在缓冲区中交错，解出错乱的帧。
+// 每个连接应该使用独立的 Frame，需要按连接管理时可以使用 FrameSet。
+//
+// Frame 内含互斥锁，只能通过指针使用，不能按值复制（复制后两份各自加锁，互斥失效）。
+// 按值传递或赋值时 go vet 的 copylocks 检查会给出警告，不需要额外的 noCopy 字段。
 type Frame struct {
-	Hc   *HeaderConfig
-	buf  []byte
-	lock sync.Mutex
+	Hc    *HeaderConfig
+	buf   []byte
+	order binary.ByteOrder // AutoByteOrder 检测后（或 SetByteOrder 设置后）锁定的字节序，nil 表示尚未锁定
+
+	resynced uint64 // 重新同步 Magic 时累计丢弃的字节数
+	decoded  uint64 // 累计取出的帧数
+	consumed uint64 // 累计从缓冲区移除的字节数
+
+	layout    headerLayout // 上一次解析时影响头部格式的配置，用于检测中途修改配置
+	hasLayout bool
+	lock      sync.Mutex
+
+	scratch []byte // ReadFromConn 的读缓冲区，首次使用时分配
+	trailer []byte // 最近取出的帧的尾部，见 TrailerLength
+
+	dropping uint64        // DropOversized 时正在丢弃的超长帧还剩下的字节数
+	drops    []int         // 尚未通过 OnDropped 通知的被丢弃帧的 body 长度
+	headers  []headerEvent // 尚未通过 OnHeader / OnRawLength 通知的帧头部
+
+	maxLen    int // SetMaxFrameLength 设置的上限，hasMaxLen 为 false 时使用 Hc.MaxFrameLength
+	hasMaxLen bool
+	committed bool // 缓冲区开头的帧头部已经通过长度检查（并已记录 OnHeader 事件），帧还没有被消费
+
+	split bool // SplitFrames 使用的临时 Frame，缓冲区就是调用方的数据，不需要为不完整的帧预分配
+
+	streaming bool   // StreamBody 时正在分块返回一个帧的 body
+	streamLen int    // 正在分块返回的 body 的总长度
+	remaining uint64 // 正在分块返回的 body 还没有到达的字节数
+
+	lastInput time.Time // 最近一次有数据追加到缓冲区的时间，见 DiscardStalePartial
+}
+
+// NewFrame 校验 hc 后创建一个初始化好缓冲区的 Frame，配置不合法时返回 Validate 的错误
+// 包外的调用方应通过 NewFrame 创建 Frame，而不是手动构造结构体
+func NewFrame(hc *HeaderConfig) (*Frame, error) {
+	if hc == nil {
+		return nil, errors.New("nil HeaderConfig")
+	}
+	if err := hc.Validate(); err != nil {
+		return nil, err
+	}
+	return newFrame(hc), nil
 }
 
+// newFrame 创建 Frame，按 InitialBufferSize 预分配缓冲区，不做校验
+func newFrame(hc *HeaderConfig) *Frame {
+	return &Frame{Hc: hc, buf: make([]byte, 0, hc.InitialBufferSize)}
+}
+
+// maxPrealloc 未配置 MaxFrameLength 时，根据长度字段一次性预分配缓冲区的上限
+const maxPrealloc = 64 * 1024
+
+// defaultDebugDumpLimit 未配置 DebugDumpLimit 时 DebugDump 最多输出的字节数
+const defaultDebugDumpLimit = 256
+
+// StripNone 用于 InitialBytesToStrip，表示不剥离任何字节，返回包含头部的完整帧
+const StripNone = -1
+
+// LengthEncoding 长度字段的编码方式
+type LengthEncoding int
+
+const (
+	LengthFixed        LengthEncoding = iota // 定长整数，占 LengthFieldLength 字节（默认）
+	LengthVarint                             // base-128 varint（protobuf 风格），长度字段本身变长
+	LengthASCIIDecimal                       // ASCII 十进制数字，以 LengthTerminator 结尾，例如 "1024 <body>"
+)
+
+// VarintFlavor LengthVarint 模式下 varint 的具体格式
+type VarintFlavor int
+
+const (
+	VarintUnsigned VarintFlavor = iota // 无符号 LEB128，与 binary.PutUvarint 相同（默认）
+	VarintZigzag                       // zigzag 编码的有符号 varint，与 binary.PutVarint 相同，解码出负数时返回 ErrNegativeLength
+)
+
+// maxASCIILengthDigits ASCII 十进制长度最多允许的数字个数，超过一定会溢出 int64
+const maxASCIILengthDigits = 19
+
 type HeaderConfig struct {
 	ByteOrder         binary.ByteOrder
-	LengthFieldLength int // 长度字段占用字节数（2 或 4）
+	LengthFieldLength int // 长度字段占用字节数（2、4 或 8），仅 LengthFixed 使用
+
+	// LengthEncoding 长度字段的编码方式，默认 LengthFixed
+	LengthEncoding LengthEncoding
+	// VarintFlavor LengthVarint 模式下 varint 的格式，默认 VarintUnsigned
+	VarintFlavor VarintFlavor
+	// LengthTerminator LengthASCIIDecimal 模式下长度数字后面的结束符，0 表示使用空格
+	// 结束符计入头部长度，默认会和数字一起被剥离
+	LengthTerminator byte
+
+	// Fields 描述由多个字段组成的定长头部（位于 Magic 之后），其中标记为 Length 的字段作为长度字段，
+	// 配置后 LengthFieldLength 和 LengthFieldOffset 不再使用，其他字段可以通过 Frame.ReadFrameWithHeader 或 ParseHeader 取得。Encode 不支持 Fields
+	Fields []FieldSpec
+
+	// LengthFunc 设置后完全取代内置的长度字段解析，用于长度分散在多个字段（例如 high<<16 | low）等特殊协议
+	// 缓冲区中收齐 Magic 之后的定长头部时调用一次，参数为这部分头部：配置了 Fields 时为 Fields 覆盖的范围，
+	// 否则为 LengthFieldOffset + LengthFieldLength 字节（此时 LengthFieldLength 可以是任意正数）。
+	// 返回值等同于长度字段的值，之后仍按 LengthAdjustment / LengthIncludesHeader 换算并检查 MaxFrameLength。Encode 不支持 LengthFunc
+	LengthFunc func(header []byte) (int, error)
+
+	// LengthFieldOffset 长度字段之前（Magic 之后）的固定字节数，例如固定长度的命令块，默认 0
+	// 这些字节计入头部长度，默认和长度字段一起被剥离；需要保留时把 InitialBytesToStrip 设置为 len(Magic)
+	// （没有 Magic 时设置为 StripNone），返回的帧为 命令块 + 长度字段 + body
+	// 编码时 body 的前 LengthFieldOffset 个字节会被写在长度字段之前，不计入长度字段的值
+	LengthFieldOffset int
+
+	// LengthShift / LengthMask 用于长度只占长度字段一部分比特的协议（例如高 4 位是标志位、低 12 位是长度）：
+	// 从长度字段（或 Fields 中的长度字段）读出原始值后，实际的长度为 (raw >> LengthShift) & LengthMask，LengthMask 为 0 表示不屏蔽。
+	// 只支持定长长度字段，不作用于 LengthFunc；编码时长度必须能放进 LengthMask，其余比特写为 0
+	LengthShift uint
+	LengthMask  uint64
+
+	// LengthUnitBytes 长度字段每个单位对应的字节数，例如以 16 位字为单位的协议设置为 2，0 表示默认 1
+	// 长度字段的值先乘以 LengthUnitBytes 换算为字节数，再应用 LengthAdjustment / LengthIncludesHeader（二者仍以字节为单位）；
+	// 编码时 body（含校验和，经过修正之后）的长度必须是 LengthUnitBytes 的整数倍
+	LengthUnitBytes int
+
+	// LengthAdjustment 加到长度字段的值上得到 body 的实际长度，用于长度字段还计入了其他内容的协议
+	LengthAdjustment int
+	// LengthIncludesHeader 为 true 表示长度字段的值包含整个头部（Magic + LengthFieldOffset + 长度字段）本身，
+	// 相当于把 LengthAdjustment 设置为负的头部长度；不能与 LengthAdjustment 同时使用
+	LengthIncludesHeader bool
+
+	// TrailerLength body（含校验和）之后固定长度的尾部字节数，不计入长度字段的值，默认 0
+	// ReadFrame 等收齐尾部之后才返回帧，返回的帧不包含尾部，最近一个帧的尾部可以通过 Frame.Trailer 取得；
+	// 编码时 body 的最后 TrailerLength 个字节作为尾部写在校验和之后
+	TrailerLength int
+
+	// MaxFrameLength body（含校验和）允许的最大长度，0 表示不限制
+	// 解码时在收齐 body 之前就会检查，避免恶意的长度字段导致无限制地缓冲；编码时同样生效。
+	// 长度字段的值（包括 8 字节长度字段）在转换为 int 之前按 uint64 与它比较，超过上限一律返回 ErrFrameTooLarge，不会被截断
+	MaxFrameLength int
+	// WarnFrameLength 低于 MaxFrameLength 的观测阈值，0 表示不启用。body（含校验和）超过它的帧照常解码返回，
+	// 只是在头部通过长度检查时调用一次 OnLargeFrame（参数为 body 长度），用来先按线上的真实数据调整 MaxFrameLength，
+	// 而不是猜一个上限之后在合法的大帧被拒绝时才发现。回调的执行时机与 OnHeader 相同
+	WarnFrameLength int
+	OnLargeFrame    func(size int)
+	// PlausibilityCheck 解析出 body 长度（已应用 LengthAdjustment 等修正）后、开始缓冲 body 之前调用，
+	// 返回 false 时 ReadFrame 返回 ErrImplausibleLength 且不消费数据。用于没有校验和的协议根据业务知识
+	// （例如“帧不会超过 8KB”“长度总是 4 的倍数”）尽早发现数据流错位
+	PlausibilityCheck func(length int) bool
+
+	// DropOversized 为 true 时，body 超过 MaxFrameLength 的帧不再返回 ErrFrameTooLarge，
+	// 而是在数据陆续到达时丢弃整个帧（头部 + body + 尾部）后继续解析下一个帧，用于单个超长帧不应断开连接的场景。
+	// 每丢弃一个帧调用一次 OnDropped，参数为这个帧声明的 body 长度。需要配置 MaxFrameLength
+	DropOversized bool
+	OnDropped     func(size int)
+
+	// MinFrameLength body（含校验和）允许的最小长度，0 表示不限制（默认，允许空帧）
+	// 用于拒绝大量无意义的极小帧，解码和编码时生效，与 MaxFrameLength 一起限定长度范围
+	MinFrameLength int
+
+	// AutoByteOrder 开启后，如果按 ByteOrder 解析出的长度超过 MaxFrameLength 而按相反字节序解析是合理的，
+	// 就改用相反的字节序并在这个 Frame 上锁定（第一个头部解析成功时也会锁定为 ByteOrder），
+	// 用于兼容字节序刷错的设备。这只是启发式判断，需要配置 MaxFrameLength 才会生效，
+	// 实际使用的字节序可以通过 Frame.DetectedByteOrder 查看
+	AutoByteOrder bool
+
+	// Magic 每个帧开头必须出现的固定字节，为空表示不检查
+	// Magic 位于帧的最开头，长度字段紧随其后（长度字段的位置从 Magic 之后开始计算），
+	// Magic 计入头部长度，默认会和长度字段一起被剥离
+	Magic []byte
+	// Resync 为 true 时，Magic 不匹配不再返回 ErrBadMagic，而是丢弃数据直到下一个 Magic 出现后继续解析，
+	// 用于会丢字节的串口等链路；丢弃的字节数见 Frame.ResyncedBytes。未配置 Magic 时不起作用
+	Resync bool
+
+	// HeaderChecksum 设置后在头部收齐、信任长度字段之前先校验头部自身的校验和，防止损坏的长度字段导致缓冲大量数据。
+	// 校验和所在的字节计入头部长度（校验和在长度字段之后时头部相应变长），只支持 LengthFixed。
+	// 不匹配时返回 ErrHeaderChecksumMismatch 且不消费数据；开启 Resync 时改为丢弃这个 Magic 并重新同步到下一个 Magic
+	HeaderChecksum *HeaderChecksum
+
+	// InitialBytesToStrip 从完整帧（header + body）开头剥离的字节数
+	// - 为 0 时保持默认行为，剥离长度字段，只返回 body
+	// - 为 StripNone 时不剥离，返回包含头部的完整帧
+	InitialBytesToStrip int
+	// ReturnFullFrame 为 true 时返回线路上的完整原始帧（头部 + body + 校验和 + 尾部），便于原样转发；
+	// 与 StripNone 的区别是校验和与尾部也会保留。不能与 InitialBytesToStrip 同时使用
+	ReturnFullFrame bool
+
+	// SkipEmptyFrames 为 true 时 body 为空（不含校验和）的帧（例如心跳）被直接消费并跳过，ReadFrame 继续解析下一个帧，
+	// 返回第一个非空的帧，缓冲区中没有非空的帧时返回 (nil, nil)。跳过的帧不触发 OnFrame，不计入 Stats 中的帧数，也不消耗 RateLimiter。
+	// 默认 false，空帧返回非 nil 的空切片
+	SkipEmptyFrames bool
+
+	// CodecSelector 设置后每个帧收齐时调用一次，参数为帧头部（从 Magic 开始），返回用来还原 body 的 BodyCodec，
+	// 返回 nil 表示这个帧原样返回，用于由头部中的标志位决定是否压缩的协议（标志位可以配合 Fields / ParseHeader 解析）。
+	// 还原后的 body 总是新分配的内存，不受 ZeroCopy / BufferPool 影响；还原失败的帧会被丢弃并返回错误，之后可以继续读取。
+	// CodecSelector 和 BodyCodec 在持有 Frame 的锁时调用，不能再调用同一个 Frame 的方法。
+	// Encode 不会自动编码 body，调用方需要先调用 BodyCodec.Encode 并自行设置头部中的标志。
+	// 不能与 InitialBytesToStrip / ReturnFullFrame 同时使用
+	CodecSelector func(header []byte) BodyCodec
+
+	// StreamBody 为 true 时可以通过 Frame.ReadFrameChunk 在 body 到达的过程中分块取出，不必把整个帧缓冲下来，
+	// 适合几 MB 以上的大帧。分块返回期间不能调用 ReadFrame 等整帧读取的方法。
+	// 不能与 ChecksumLength / TrailerLength / CodecSelector / ReturnFullFrame / InitialBytesToStrip 同时使用
+	StreamBody bool
+
+	// ZeroCopy 为 true 时 ReadFrame 直接返回内部缓冲区的切片，省去一次分配和拷贝
+	// 注意：返回的切片只在下一次调用 ReadFrame 之前有效，之后其内容可能被新数据覆盖，
+	// 需要保留的数据必须由调用方自行拷贝。默认 false，每个帧都返回一份独立的拷贝
+	ZeroCopy bool
+
+	// RateLimiter 设置后每个收齐的帧在返回之前调用一次 Allow，不允许时返回 ErrRateLimited，用于防止单个客户端发送过多的帧。
+	// 默认这个帧保留在缓冲区中，之后（例如等待一段时间后）再次读取即可取出；RateLimitDrop 为 true 时改为丢弃这个帧。
+	// 同一个 HeaderConfig 被多个 Frame 共用时它们共享同一个限制器，需要按连接限速时每个连接应使用独立的 HeaderConfig
+	RateLimiter   RateLimiter
+	RateLimitDrop bool
+
+	// HighWaterMark 缓冲区中尚未消费的数据的上限（字节），0 表示不限制（默认）
+	// Frame.Feed 只接收缓冲区放得下的部分输入，并通过 consumed 告诉调用方，ReadFromConn 每次最多读取缓冲区剩余的空间，
+	// 这样调用方可以停止从 socket 读取，把背压交给 TCP。ReadFrame 无法报告只接收了部分输入，不受 HighWaterMark 限制。
+	// 必须能容纳最大的完整帧（头部 + body + 尾部），否则缓冲区满时返回 ErrHighWaterMark；
+	// 配置了 MaxFrameLength 时 Validate 和 SetMaxFrameLength 按头部的最大长度 + MaxFrameLength + TrailerLength 检查
+	HighWaterMark int
+
+	// InitialBufferSize 通过 NewFrame 创建时缓冲区的初始容量，按预期的帧大小设置可以减少连接建立初期的扩容
+	InitialBufferSize int
+
+	// BufferPool 设置后 ReadFrame 从池中获取存放返回帧的内存，调用方处理完帧后必须调用 Frame.Release 归还，
+	// 适合每个帧处理完立即丢弃的高吞吐场景。池中存放的是 *[]byte，容量不足的对象会被丢弃并重新分配。
+	// 帧归还之后就可能被下一个 ReadFrame 复用，调用方不能再读写它，也不能重复归还。ZeroCopy 时不使用
+	BufferPool *sync.Pool
+
+	// ShrinkThreshold 缓冲区容量收缩阈值（字节），0 表示关闭（默认）
+	// 开启后每次取出帧时，如果容量超过阈值且超过剩余数据长度的 4 倍，就重新分配一个更小的缓冲区，
+	// 避免偶尔收到一个大包后长连接一直占用这块内存
+	ShrinkThreshold int
+
+	// DebugDumpLimit Frame.DebugDump 最多输出的字节数，0 表示默认 256 字节
+	DebugDumpLimit int
+
+	// OnFrame 每取出一个完整帧时调用，参数为返回给调用方的帧长度
+	// OnHeader 每个帧的头部解析出来、通过长度检查时调用一次，参数为 body 长度，此时 body 可能还没有到达，
+	// 可以用来提前准备接收 body 的空间。同一个帧分多次到达时只触发一次，body 收齐后仍由 ReadFrame 返回并触发 OnFrame
+	// OnError ReadFrame 返回错误（头部解析失败、超过长度上限、校验失败等）时调用
+	//
+	// 回调在 ReadFrame 释放锁之后、返回之前执行，因此回调中调用同一个 Frame 的方法不会死锁，
+	// 但多个 goroutine 同时调用 ReadFrame 时回调也可能并发执行，回调本身需要是并发安全的。
+	// 同一个 HeaderConfig 被多个 Frame 共用时，回调会收到所有 Frame 的事件，适合直接对接 Prometheus / expvar 计数
+	OnFrame  func(size int)
+	OnHeader func(length int)
+	OnError  func(err error)
+
+	// OnRawLength 与 OnHeader 同时触发，参数为长度字段应用 LengthShift / LengthMask 之前的原始值，
+	// 用于读取和长度共用一个字段的标志位（例如最高位表示压缩）。只支持定长长度字段，配置了 LengthFunc 或变长长度时不触发
+	OnRawLength func(raw uint64)
+
+	// ChecksumLength 帧尾校验和占用的字节数（1、2 或 4），0 表示不校验
+	// 校验和位于 body 末尾并计入长度字段，按 ByteOrder 编码，返回的帧不包含校验和
+	ChecksumLength int
+	// ChecksumFunc 计算 body 的校验和，例如 crc32.ChecksumIEEE，结果按 ChecksumLength 截断
+	ChecksumFunc func([]byte) uint32
+}
+
+// headerLayout 影响头部解析的配置项
+type headerLayout struct {
+	byteOrder            binary.ByteOrder
+	lengthFieldLength    int
+	lengthFieldOffset    int
+	trailerLength        int
+	lengthEncoding       LengthEncoding
+	lengthTerminator     byte
+	varintFlavor         VarintFlavor
+	lengthAdjustment     int
+	lengthIncludesHeader bool
+	lengthUnitBytes      int
+	lengthShift          uint
+	lengthMask           uint64
+	magic                []byte
+	fields               []FieldSpec
+	headerChecksum       *HeaderChecksum
+}
+
+func (hc *HeaderConfig) headerLayout() headerLayout {
+	return headerLayout{
+		byteOrder:            hc.ByteOrder,
+		lengthFieldLength:    hc.LengthFieldLength,
+		lengthFieldOffset:    hc.LengthFieldOffset,
+		trailerLength:        hc.TrailerLength,
+		lengthEncoding:       hc.LengthEncoding,
+		lengthTerminator:     hc.LengthTerminator,
+		varintFlavor:         hc.VarintFlavor,
+		lengthAdjustment:     hc.LengthAdjustment,
+		lengthIncludesHeader: hc.LengthIncludesHeader,
+		lengthUnitBytes:      hc.LengthUnitBytes,
+		lengthShift:          hc.LengthShift,
+		lengthMask:           hc.LengthMask,
+		magic:                hc.Magic,
+		fields:               hc.Fields,
+		headerChecksum:       hc.HeaderChecksum,
+	}
+}
+
+func (l headerLayout) equal(o headerLayout) bool {
+	return l.byteOrder == o.byteOrder &&
+		l.lengthFieldLength == o.lengthFieldLength &&
+		l.lengthFieldOffset == o.lengthFieldOffset &&
+		l.trailerLength == o.trailerLength &&
+		l.lengthEncoding == o.lengthEncoding &&
+		l.lengthTerminator == o.lengthTerminator &&
+		l.varintFlavor == o.varintFlavor &&
+		l.lengthAdjustment == o.lengthAdjustment &&
+		l.lengthIncludesHeader == o.lengthIncludesHeader &&
+		l.lengthUnitBytes == o.lengthUnitBytes &&
+		l.lengthShift == o.lengthShift &&
+		l.lengthMask == o.lengthMask &&
+		bytes.Equal(l.magic, o.magic) &&
+		slices.Equal(l.fields, o.fields) &&
+		l.headerChecksum == o.headerChecksum
+}
+
+// Validate 检查配置是否合法，让配置错误在创建 Frame 时就暴露，而不是等到第一个包到达
+func (hc *HeaderConfig) Validate() error {
+	switch {
+	case len(hc.Fields) == 0:
+	case hc.LengthEncoding != LengthFixed:
+		return errors.New("Fields requires LengthFixed")
+	default:
+		if err := hc.validateFields(); err != nil {
+			return err
+		}
+	}
+	if hc.LengthFunc != nil && hc.LengthEncoding != LengthFixed {
+		return errors.New("LengthFunc requires LengthFixed")
+	}
+	if hc.HeaderChecksum != nil {
+		if hc.LengthEncoding != LengthFixed {
+			return errors.New("HeaderChecksum requires LengthFixed")
+		}
+		if err := hc.HeaderChecksum.validate(hc.ByteOrder); err != nil {
+			return err
+		}
+	}
+
+	switch hc.LengthEncoding {
+	case LengthFixed:
+		if len(hc.Fields) > 0 {
+			break
+		}
+		if hc.LengthFunc != nil {
+			if hc.LengthFieldLength <= 0 {
+				return errors.New("LengthFieldLength must be positive")
+			}
+			break
+		}
+		if hc.LengthFieldLength != 2 && hc.LengthFieldLength != 4 && hc.LengthFieldLength != 8 {
+			return errors.New("unsupported LengthFieldLength, only 2, 4 or 8")
+		}
+		if hc.ByteOrder == nil {
+			return errors.New("ByteOrder is required")
+		}
+		if err := hc.validateLengthMask(hc.LengthFieldLength); err != nil {
+			return err
+		}
+	case LengthVarint:
+		if hc.VarintFlavor != VarintUnsigned && hc.VarintFlavor != VarintZigzag {
+			return errors.New("unsupported VarintFlavor")
+		}
+	case LengthASCIIDecimal:
+		if t := hc.terminator(); t >= '0' && t <= '9' {
+			return errors.New("LengthTerminator must not be a digit")
+		}
+	default:
+		return errors.New("unsupported LengthEncoding")
+	}
+
+	if hc.TrailerLength < 0 {
+		return errors.New("TrailerLength must not be negative")
+	}
+	if hc.LengthFieldOffset < 0 {
+		return errors.New("LengthFieldOffset must not be negative")
+	}
+	if hc.LengthIncludesHeader && hc.LengthAdjustment != 0 {
+		return errors.New("LengthIncludesHeader and LengthAdjustment are mutually exclusive")
+	}
+	if (hc.LengthShift != 0 || hc.LengthMask != 0) && (hc.LengthEncoding != LengthFixed || hc.LengthFunc != nil) {
+		return errors.New("LengthShift and LengthMask require a fixed length field")
+	}
+	if hc.LengthUnitBytes < 0 {
+		return errors.New("LengthUnitBytes must not be negative")
+	}
+	if hc.MaxFrameLength < 0 {
+		return errors.New("MaxFrameLength must not be negative")
+	}
+	if hc.MinFrameLength < 0 {
+		return errors.New("MinFrameLength must not be negative")
+	}
+	if hc.MaxFrameLength > 0 && hc.MinFrameLength > hc.MaxFrameLength {
+		return errors.New("MinFrameLength exceeds MaxFrameLength")
+	}
+	if hc.WarnFrameLength < 0 {
+		return errors.New("WarnFrameLength must not be negative")
+	}
+	if hc.MaxFrameLength > 0 && hc.WarnFrameLength >= hc.MaxFrameLength {
+		return errors.New("WarnFrameLength must be less than MaxFrameLength")
+	}
+	if hc.DropOversized && hc.MaxFrameLength == 0 {
+		return errors.New("DropOversized requires MaxFrameLength")
+	}
+	if hc.AutoByteOrder && hc.MaxFrameLength == 0 {
+		return errors.New("AutoByteOrder requires MaxFrameLength")
+	}
+	if hc.InitialBytesToStrip < StripNone {
+		return errors.New("invalid InitialBytesToStrip")
+	}
+	if hc.ReturnFullFrame && hc.InitialBytesToStrip != 0 {
+		return errors.New("ReturnFullFrame and InitialBytesToStrip are mutually exclusive")
+	}
+	if hc.CodecSelector != nil && (hc.InitialBytesToStrip != 0 || hc.ReturnFullFrame) {
+		return errors.New("CodecSelector requires the default InitialBytesToStrip and no ReturnFullFrame")
+	}
+	if hc.StreamBody && (hc.ChecksumLength > 0 || hc.TrailerLength > 0 || hc.CodecSelector != nil || hc.ReturnFullFrame || hc.InitialBytesToStrip != 0) {
+		return errors.New("StreamBody cannot be used with ChecksumLength, TrailerLength, CodecSelector, ReturnFullFrame or InitialBytesToStrip")
+	}
+	if hc.HighWaterMark < 0 {
+		return errors.New("HighWaterMark must not be negative")
+	}
+	if err := hc.validateHighWaterMark(hc.MaxFrameLength); err != nil {
+		return err
+	}
+	if hc.InitialBufferSize < 0 {
+		return errors.New("InitialBufferSize must not be negative")
+	}
+	if hc.ShrinkThreshold < 0 {
+		return errors.New("ShrinkThreshold must not be negative")
+	}
+	if hc.DebugDumpLimit < 0 {
+		return errors.New("DebugDumpLimit must not be negative")
+	}
+
+	switch hc.ChecksumLength {
+	case 0:
+	case 1, 2, 4:
+		if hc.ChecksumFunc == nil {
+			return errors.New("ChecksumFunc is required when ChecksumLength is set")
+		}
+		if hc.ChecksumLength > 1 && hc.ByteOrder == nil {
+			return errors.New("ByteOrder is required")
+		}
+	default:
+		return errors.New("unsupported ChecksumLength, only 1, 2 or 4")
+	}
+
+	return nil
+}
+
+// validateHighWaterMark 检查 HighWaterMark 能否容纳 body 为 maxLen 字节时线路上最长的完整帧（头部 + body + 尾部）
+// 未配置 HighWaterMark 或者 maxLen 为 0（不限制）时不检查
+func (hc *HeaderConfig) validateHighWaterMark(maxLen int) error {
+	if hc.HighWaterMark == 0 || maxLen == 0 {
+		return nil
+	}
+	// 先减后比较，maxLen 很大时也不会溢出
+	if hc.HighWaterMark < maxLen || hc.HighWaterMark-maxLen < hc.maxHeaderLen()+hc.TrailerLength {
+		return errors.New("HighWaterMark must hold the largest frame (header + MaxFrameLength + trailer)")
+	}
+	return nil
+}
+
+// maxHeaderLen 返回头部（从 Magic 开始，含头部校验和）最多占用的字节数，变长的长度字段按可能的最大长度计算
+func (hc *HeaderConfig) maxHeaderLen() int {
+	n := len(hc.Magic)
+	switch {
+	case hc.LengthFunc != nil || len(hc.Fields) > 0:
+		n += hc.fixedHeaderLen()
+	case hc.LengthEncoding == LengthVarint:
+		n += hc.LengthFieldOffset + binary.MaxVarintLen64
+	case hc.LengthEncoding == LengthASCIIDecimal:
+		n += hc.LengthFieldOffset + maxASCIILengthDigits + 1
+	default:
+		n += hc.LengthFieldOffset + hc.LengthFieldLength
+	}
+	if hcs := hc.HeaderChecksum; hcs != nil {
+		n = max(n, len(hc.Magic)+hcs.Offset+hcs.Width)
+	}
+	return n
 }
 
 // Parse 根据配置解析出包体总长度（body 的长度，不包含长度字段本身）
+// 长度超出 int 的表示范围（例如 32 位平台上的 0xFFFFFFFF）时返回 ErrLengthOverflow，而不是一个负数
 func (hc *HeaderConfig) Parse(header []byte) (int, error) {
+	return hc.parseFixed(header, hc.ByteOrder)
+}
+
+// ParseUint64 与 Parse 相同，但返回长度字段的无符号值（已应用 LengthShift / LengthMask），不做 int 转换
+func (hc *HeaderConfig) ParseUint64(header []byte) (uint64, error) {
+	return hc.parseFixedUint64(header, hc.ByteOrder)
+}
+
+// parseFixed 与 Parse 相同，但使用指定的字节序
+func (hc *HeaderConfig) parseFixed(header []byte, order binary.ByteOrder) (int, error) {
+	v, err := hc.parseFixedUint64(header, order)
+	if err != nil {
+		return 0, err
+	}
+	if v > math.MaxInt {
+		return 0, ErrLengthOverflow
+	}
+	return int(v), nil
+}
+
+// parseFixedUint64 与 ParseUint64 相同，但使用指定的字节序
+func (hc *HeaderConfig) parseFixedUint64(header []byte, order binary.ByteOrder) (uint64, error) {
+	raw, err := hc.rawFixedUint64(header, order)
+	if err != nil {
+		return 0, err
+	}
+	return hc.maskLength(raw), nil
+}
+
+// rawFixedUint64 读取定长长度字段的原始值，不应用 LengthShift / LengthMask
+func (hc *HeaderConfig) rawFixedUint64(header []byte, order binary.ByteOrder) (uint64, error) {
 	if len(header) < hc.LengthFieldLength {
 		return 0, errors.New("header too short")
 	}
 
+	// 2 字节大端序是最常见的配置，直接按字节拼出长度，省去 ByteOrder 的接口调用
+	// 用类型断言判断而不是 order == binary.BigEndian，接口的相等比较本身比一次接口调用还慢
+	if hc.LengthFieldLength == 2 && isOrder(order, binary.BigEndian) {
+		return uint64(header[0])<<8 | uint64(header[1]), nil
+	}
+
 	switch hc.LengthFieldLength {
 	case 2:
-		return int(hc.ByteOrder.Uint16(header)), nil
+		return uint64(order.Uint16(header)), nil
+	case 4:
+		return uint64(order.Uint32(header)), nil
+	case 8:
+		return order.Uint64(header), nil
+	default:
+		return 0, errors.New("unsupported LengthFieldLength, only 2, 4 or 8")
+	}
+}
+
+// rawLength 从完整的头部 header 中读取长度字段的原始值（应用 LengthShift / LengthMask 之前），
+// 只支持定长长度字段（LengthFieldLength 或 Fields 中的长度字段），其他情况 ok 为 false
+func (hc *HeaderConfig) rawLength(header []byte, order binary.ByteOrder) (raw uint64, ok bool) {
+	if hc.LengthEncoding != LengthFixed || hc.LengthFunc != nil {
+		return 0, false
+	}
+	header = header[len(hc.Magic):]
+	if len(hc.Fields) > 0 {
+		for _, field := range hc.Fields {
+			if field.Length {
+				return field.value(header, order), true
+			}
+		}
+		return 0, false
+	}
+	raw, err := hc.rawFixedUint64(header[hc.LengthFieldOffset:], order)
+	return raw, err == nil
+}
+
+// maskLength 按 LengthShift / LengthMask 从长度字段的原始值中取出长度
+func (hc *HeaderConfig) maskLength(raw uint64) uint64 {
+	v := raw >> hc.LengthShift
+	if hc.LengthMask != 0 {
+		v &= hc.LengthMask
+	}
+	return v
+}
+
+// validateLengthMask 检查 LengthShift / LengthMask 是否在 width 字节的长度字段范围之内
+func (hc *HeaderConfig) validateLengthMask(width int) error {
+	bits := uint(8 * width)
+	if hc.LengthShift >= bits {
+		return errors.New("LengthShift exceeds length field width")
+	}
+	if hc.LengthMask>>(bits-hc.LengthShift) != 0 {
+		return errors.New("LengthMask exceeds length field width")
+	}
+	return nil
+}
+
+// isOrder 判断 order 的动态类型是否与 want 相同，T 由 want 推导出来，因此不需要引用 binary 包中未导出的类型
+func isOrder[T binary.ByteOrder](order binary.ByteOrder, want T) bool {
+	_, ok := order.(T)
+	return ok
+}
+
+// parseHeader 从 buf 开头校验 Magic 并解析长度字段，返回 body 长度和头部（Magic + LengthFieldOffset + 长度字段）的长度
+// - 数据不足以解析出长度时 ok 为 false
+// - 定长长度字段按 order 解析
+// - body 超过 limit 时返回 ErrFrameTooLarge，limit 为 0 表示不限制
+func (hc *HeaderConfig) parseHeader(buf []byte, order binary.ByteOrder, limit int) (bodyLen, headerLen int, ok bool, err error) {
+	if n := len(hc.Magic); n > 0 {
+		// 已到达的部分不匹配就可以提前报错，不必等 Magic 收齐
+		if len(buf) < n {
+			if !bytes.HasPrefix(hc.Magic, buf) {
+				return 0, 0, false, ErrBadMagic
+			}
+			return 0, 0, false, nil
+		}
+		if !bytes.Equal(buf[:n], hc.Magic) {
+			return 0, 0, false, ErrBadMagic
+		}
+	}
+
+	// 长度在 uint64 上解析和修正，先与 limit 比较再转换为 int，8 字节长度字段的任何取值都不会回绕
+	var value uint64
+	switch {
+	case hc.LengthFunc != nil:
+		n := hc.fixedHeaderLen()
+		if len(buf) < len(hc.Magic)+n {
+			return 0, 0, false, nil
+		}
+		v, err := hc.LengthFunc(buf[len(hc.Magic) : len(hc.Magic)+n])
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if v < 0 {
+			return 0, 0, false, fmt.Errorf("%w from LengthFunc: %d", ErrNegativeLength, v)
+		}
+		value, headerLen = uint64(v), len(hc.Magic)+n
+	case len(hc.Fields) > 0:
+		v, n, ok := hc.parseFieldsLength(buf[len(hc.Magic):], order)
+		if !ok {
+			return 0, 0, false, nil
+		}
+		value, headerLen = v, len(hc.Magic)+n
+	default:
+		offset := len(hc.Magic) + hc.LengthFieldOffset
+		if len(buf) < offset {
+			return 0, 0, false, nil
+		}
+		v, lengthLen, ok, err := hc.parseLength(buf[offset:], order)
+		if !ok || err != nil {
+			return 0, 0, false, err
+		}
+		value, headerLen = v, offset+lengthLen
+	}
+
+	if hc.HeaderChecksum != nil {
+		end, ok, err := hc.verifyHeaderChecksum(buf)
+		if !ok || err != nil {
+			return 0, 0, false, err
+		}
+		headerLen = max(headerLen, end)
+	}
+
+	length, err := hc.adjustLength(value, headerLen)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if limit > 0 && length > uint64(limit) {
+		// 返回解析出的长度，供 DropOversized 计算需要丢弃的字节数；超出 int 的长度按 math.MaxInt 返回
+		return int(min(length, math.MaxInt)), headerLen, false, ErrFrameTooLarge
+	}
+	if length > math.MaxInt {
+		return 0, 0, false, ErrLengthOverflow
+	}
+	bodyLen = int(length)
+	if bodyLen < hc.MinFrameLength {
+		return 0, 0, false, ErrFrameTooSmall
+	}
+	if hc.PlausibilityCheck != nil && !hc.PlausibilityCheck(bodyLen) {
+		return 0, 0, false, ErrImplausibleLength
+	}
+	return bodyLen, headerLen, true, nil
+}
+
+// fixedHeaderLen 返回 LengthFunc 需要的定长头部（不含 Magic）的长度
+func (hc *HeaderConfig) fixedHeaderLen() int {
+	if len(hc.Fields) > 0 {
+		return hc.fieldsLen()
+	}
+	return hc.LengthFieldOffset + hc.LengthFieldLength
+}
+
+// lengthUnit 返回长度字段每个单位对应的字节数
+func (hc *HeaderConfig) lengthUnit() int {
+	if hc.LengthUnitBytes == 0 {
+		return 1
+	}
+	return hc.LengthUnitBytes
+}
+
+// adjustLength 根据 LengthUnitBytes / LengthAdjustment / LengthIncludesHeader 把长度字段的值换算为 body 长度
+// 结果可能超出 int，由调用方与上限比较之后再转换；超出 uint64 时返回 ErrLengthOverflow
+func (hc *HeaderConfig) adjustLength(value uint64, headerLen int) (uint64, error) {
+	if unit := uint64(hc.lengthUnit()); unit > 1 {
+		if value > math.MaxUint64/unit {
+			return 0, ErrLengthOverflow
+		}
+		value *= unit
+	}
+
+	adjustment, err := hc.lengthAdjustment(headerLen)
+	if err != nil {
+		return 0, err
+	}
+	if adjustment >= 0 {
+		if value > math.MaxUint64-uint64(adjustment) {
+			return 0, ErrLengthOverflow
+		}
+		return value + uint64(adjustment), nil
+	}
+	// 先加 1 再取反，adjustment 为 math.MinInt 时也不会溢出
+	if sub := uint64(-(adjustment + 1)) + 1; value >= sub {
+		return value - sub, nil
+	}
+	return 0, fmt.Errorf("%w after adjustment: length field %d, adjustment %d", ErrNegativeLength, value, adjustment)
+}
+
+// lengthAdjustment 返回需要加到长度字段值上的修正量
+func (hc *HeaderConfig) lengthAdjustment(headerLen int) (int, error) {
+	if hc.LengthIncludesHeader {
+		if hc.LengthAdjustment != 0 {
+			return 0, errors.New("LengthIncludesHeader and LengthAdjustment are mutually exclusive")
+		}
+		return -headerLen, nil
+	}
+	return hc.LengthAdjustment, nil
+}
+
+// parseLength 从 buf 开头解析长度字段，返回长度字段的值和长度字段实际占用的字节数
+// - 数据不足以解析出长度时 ok 为 false
+// - 返回的值没有转换为 int，由 parseHeader 修正并检查上限
+func (hc *HeaderConfig) parseLength(buf []byte, order binary.ByteOrder) (value uint64, headerLen int, ok bool, err error) {
+	switch hc.LengthEncoding {
+	case LengthFixed:
+		if len(buf) < hc.LengthFieldLength {
+			return 0, 0, false, nil
+		}
+		value, err = hc.parseFixedUint64(buf[:hc.LengthFieldLength], order)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		return value, hc.LengthFieldLength, true, nil
+	case LengthVarint:
+		v, n := binary.Uvarint(buf)
+		if n == 0 {
+			// 已经攒够 MaxVarintLen64 字节仍然没有结束，不可能是合法的 varint
+			if len(buf) >= binary.MaxVarintLen64 {
+				return 0, 0, false, errors.New("varint length overflow")
+			}
+			return 0, 0, false, nil
+		}
+		if n < 0 {
+			return 0, 0, false, errors.New("varint length overflow")
+		}
+		if hc.VarintFlavor == VarintZigzag {
+			// 最低位是符号位，与 binary.Varint 的解码方式相同
+			if v&1 != 0 {
+				return 0, 0, false, ErrNegativeLength
+			}
+			v >>= 1
+		}
+		return v, n, true, nil
+	case LengthASCIIDecimal:
+		v, n, ok, err := hc.parseASCIILength(buf)
+		return uint64(v), n, ok, err
+	default:
+		return 0, 0, false, errors.New("unsupported LengthEncoding")
+	}
+}
+
+// terminator 返回 LengthASCIIDecimal 模式下的结束符
+func (hc *HeaderConfig) terminator() byte {
+	if hc.LengthTerminator == 0 {
+		return ' '
+	}
+	return hc.LengthTerminator
+}
+
+// parseASCIILength 解析以结束符结尾的 ASCII 十进制长度，数字可以分多次到达
+func (hc *HeaderConfig) parseASCIILength(buf []byte) (bodyLen, headerLen int, ok bool, err error) {
+	term := hc.terminator()
+	for i, b := range buf {
+		if b == term {
+			if i == 0 {
+				return 0, 0, false, errors.New("empty ASCII length")
+			}
+			bodyLen, err = strconv.Atoi(string(buf[:i]))
+			if err != nil {
+				return 0, 0, false, errors.New("ASCII length overflow")
+			}
+			return bodyLen, i + 1, true, nil
+		}
+		if b < '0' || b > '9' {
+			return 0, 0, false, fmt.Errorf("invalid byte %#x in ASCII length", b)
+		}
+		if i >= maxASCIILengthDigits {
+			return 0, 0, false, errors.New("ASCII length overflow")
+		}
+	}
+	return 0, 0, false, nil
+}
+
+// getBuffer 返回长度为 n 的内存，配置了 BufferPool 时优先从池中获取
+func (hc *HeaderConfig) getBuffer(n int) []byte {
+	if hc.BufferPool != nil {
+		if p, ok := hc.BufferPool.Get().(*[]byte); ok && cap(*p) >= n {
+			return (*p)[:n]
+		}
+	}
+	return make([]byte, n)
+}
+
+// checksum 计算 body 的校验和并截断到 ChecksumLength 字节
+func (hc *HeaderConfig) checksum(body []byte) (uint32, error) {
+	if hc.ChecksumFunc == nil {
+		return 0, errors.New("ChecksumFunc is required when ChecksumLength is set")
+	}
+	sum := hc.ChecksumFunc(body)
+	switch hc.ChecksumLength {
+	case 1:
+		return sum & 0xFF, nil
+	case 2:
+		return sum & 0xFFFF, nil
+	case 4:
+		return sum, nil
+	default:
+		return 0, errors.New("unsupported ChecksumLength, only 1, 2 or 4")
+	}
+}
+
+// verifyChecksum 校验 payload（body + 校验和）末尾的校验和，返回去掉校验和后的 body
+func (hc *HeaderConfig) verifyChecksum(payload []byte) ([]byte, error) {
+	if len(payload) < hc.ChecksumLength {
+		return nil, errors.New("frame shorter than checksum")
+	}
+	body := payload[:len(payload)-hc.ChecksumLength]
+	trailer := payload[len(body):]
+
+	expected, err := hc.checksum(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var actual uint32
+	switch hc.ChecksumLength {
+	case 1:
+		actual = uint32(trailer[0])
+	case 2:
+		actual = uint32(hc.ByteOrder.Uint16(trailer))
 	case 4:
-		return int(hc.ByteOrder.Uint32(header)), nil
+		actual = hc.ByteOrder.Uint32(trailer)
+	}
+	if expected != actual {
+		return nil, &ChecksumError{Expected: expected, Actual: actual}
+	}
+	return body, nil
+}
+
+// stripLen 返回需要从完整帧开头剥离的字节数，headerLen 为本帧头部的实际长度
+func (hc *HeaderConfig) stripLen(headerLen int) (int, error) {
+	switch {
+	case hc.InitialBytesToStrip == 0:
+		return headerLen, nil
+	case hc.InitialBytesToStrip == StripNone:
+		return 0, nil
+	case hc.InitialBytesToStrip < 0:
+		return 0, errors.New("invalid InitialBytesToStrip")
 	default:
-		return 0, errors.New("unsupported LengthFieldLength, only 2 or 4")
+		return hc.InitialBytesToStrip, nil
 	}
 }
 
-// ReadFrame 输入一次从 conn 读到的数据，输出一个完整包（仅 body 部分）
+// byteOrder 返回当前解析长度字段使用的字节序
+func (f *Frame) byteOrder() binary.ByteOrder {
+	if f.order != nil {
+		return f.order
+	}
+	return f.Hc.ByteOrder
+}
+
+// headerByteOrder 与 byteOrder 相同，但自己加锁，供在锁外读取已取出帧的头部字段的调用方使用
+func (f *Frame) headerByteOrder() binary.ByteOrder {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.byteOrder()
+}
+
+// parseHeader 解析缓冲区开头的头部，开启 AutoByteOrder 时在第一个头部上检测并锁定字节序
+func (f *Frame) parseHeader() (bodyLen, headerLen int, ok bool, err error) {
+	bodyLen, headerLen, order, ok, err := f.peekHeader()
+	if ok && err == nil && f.autoByteOrder() {
+		f.order = order
+	}
+	return bodyLen, headerLen, ok, err
+}
+
+// peekHeader 与 parseHeader 相同（包括 AutoByteOrder 按相反字节序的尝试），但不锁定字节序，
+// 同时返回解析成功时使用的字节序，供 PeekLength 等只读的方法使用，调用方需持有锁
+func (f *Frame) peekHeader() (bodyLen, headerLen int, order binary.ByteOrder, ok bool, err error) {
+	order = f.byteOrder()
+	limit := f.headerLimit()
+	bodyLen, headerLen, ok, err = f.Hc.parseHeader(f.buf, order, limit)
+	if f.autoByteOrder() && errors.Is(err, ErrFrameTooLarge) {
+		other := oppositeByteOrder(order)
+		if b, h, ok2, err2 := f.Hc.parseHeader(f.buf, other, limit); ok2 && err2 == nil {
+			return b, h, other, true, nil
+		}
+	}
+	return bodyLen, headerLen, order, ok, err
+}
+
+// autoByteOrder 返回是否还需要按 AutoByteOrder 检测字节序（开启了 AutoByteOrder 且尚未锁定），调用方需持有锁
+func (f *Frame) autoByteOrder() bool {
+	return f.Hc.AutoByteOrder && f.order == nil && f.Hc.LengthEncoding == LengthFixed
+}
+
+// maxFrameLength 返回当前生效的 MaxFrameLength，调用方需持有锁
+func (f *Frame) maxFrameLength() int {
+	if f.hasMaxLen {
+		return f.maxLen
+	}
+	return f.Hc.MaxFrameLength
+}
+
+// headerLimit 返回解析缓冲区开头的头部时使用的长度上限，已经通过检查的帧不再受之后修改的上限影响
+func (f *Frame) headerLimit() int {
+	if f.committed {
+		return 0
+	}
+	return f.maxFrameLength()
+}
+
+// SetMaxFrameLength 在运行时修改这个 Frame 的 MaxFrameLength，可以与 ReadFrame 并发调用，0 表示不限制
+// - 只影响调用之后才解析出头部的帧，头部已经解析、body 还在接收的帧仍按原来的上限处理
+// - 不修改 Hc，同一个 HeaderConfig 上的其他 Frame 不受影响
+// - n 为负数、小于 MinFrameLength、不大于 WarnFrameLength、HighWaterMark 容纳不下这么长的帧，
+// 或者为 0 但配置了 DropOversized / AutoByteOrder 时返回错误，上限保持不变
+func (f *Frame) SetMaxFrameLength(n int) error {
+	switch {
+	case n < 0:
+		return errors.New("MaxFrameLength must not be negative")
+	case n > 0 && n < f.Hc.MinFrameLength:
+		return errors.New("MinFrameLength exceeds MaxFrameLength")
+	case n == 0 && f.Hc.DropOversized:
+		return errors.New("DropOversized requires MaxFrameLength")
+	case n == 0 && f.Hc.AutoByteOrder:
+		return errors.New("AutoByteOrder requires MaxFrameLength")
+	case n > 0 && f.Hc.WarnFrameLength >= n:
+		return errors.New("WarnFrameLength must be less than MaxFrameLength")
+	}
+	if err := f.Hc.validateHighWaterMark(n); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.maxLen, f.hasMaxLen = n, true
+	return nil
+}
+
+// MaxFrameLength 返回这个 Frame 当前生效的 MaxFrameLength，没有调用过 SetMaxFrameLength 时等于 Hc.MaxFrameLength
+func (f *Frame) MaxFrameLength() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.maxFrameLength()
+}
+
+// oppositeByteOrder 返回与 order 相反的字节序
+func oppositeByteOrder(order binary.ByteOrder) binary.ByteOrder {
+	if order == binary.BigEndian {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// DetectedByteOrder 返回 AutoByteOrder 或 SetByteOrder 锁定的字节序，尚未锁定（或未开启 AutoByteOrder 也没有调用过 SetByteOrder）时返回 nil
+func (f *Frame) DetectedByteOrder() binary.ByteOrder {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.order
+}
+
+// SetByteOrder 在帧边界切换这个 Frame 解析头部使用的字节序，可以与 ReadFrame 并发调用
+// - 缓冲区中还有数据（半个帧、正在丢弃或分块返回的帧）时返回 ErrConfigChangedMidFrame，字节序保持不变
+// - 设置后与 AutoByteOrder 检测的结果一样锁定在这个 Frame 上，不再自动检测；bo 为 nil 时恢复为 Hc.ByteOrder（开启了 AutoByteOrder 时重新检测）
+// - 与 AutoByteOrder 相同，只影响头部字段（包括 Dispatcher、PriorityFrame 读取的类型字段，没有单独指定字节序时），校验和仍按 Hc.ByteOrder 解析；不修改 Hc，同一个 HeaderConfig 上的其他 Frame 不受影响
+func (f *Frame) SetByteOrder(bo binary.ByteOrder) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.buf) > 0 || f.streaming || f.dropping > 0 {
+		return ErrConfigChangedMidFrame
+	}
+	f.order = bo
+	return nil
+}
+
+// ReadFrame 输入一次从 conn 读到的数据，输出一个完整包（默认仅 body 部分，见 InitialBytesToStrip）
 // - 如果数据不足，返回 (nil, nil)，等待下次补充
+// - body 长度为 0 的帧（例如心跳）返回非 nil 的空切片，调用方应通过 body != nil 而不是 len(body) > 0 判断是否取出了帧（配置了 SkipEmptyFrames 时跳过空帧）
 // - 如果有多个包，调用方需要多次调用 ReadFrame 才能依次取出
+// - raw 为 nil 与空切片相同，表示不输入新数据、只尝试取出缓冲区中已经完整的帧：缓冲区为空或帧还不完整时返回 (nil, nil)
+// - raw 总是被拷贝到内部缓冲区，ReadFrame 返回后不再引用 raw，调用方可以立即复用或覆盖自己的读缓冲区（ZeroCopy 时返回的切片引用的也是内部缓冲区而不是 raw）
+// - 配置了 ChecksumLength 时，校验失败的帧会被丢弃并返回 *ChecksumError，之后可以继续读取
+// - 配置了 Magic 时，不匹配返回 ErrBadMagic 且不消费任何数据，可以调用 SkipToMagic 重新同步
+// - body 超过 MaxFrameLength 时返回 ErrFrameTooLarge，这个帧保留在缓冲区中，调用 Recover 才会丢弃它；配置了 DropOversized 时改为自动丢弃这个帧并继续解析
+// - 头部解析成功但按 LengthAdjustment 等修正后 body 长度为负数时返回 ErrNegativeLength，可以与 ErrFrameTooLarge 分开统计
+// - 缓冲区中还有未完成的帧时修改了长度字段宽度、字节序等头部配置，返回 ErrConfigChangedMidFrame（本次输入仍会追加到缓冲区）
+//
+// 头部配置只能在缓冲区为空（帧边界）时修改，否则按旧配置到达的半个头部会被新配置错误地解释
 func (f *Frame) ReadFrame(raw []byte) ([]byte, error) {
 	f.lock.Lock()
-	defer f.lock.Unlock()
+	body, err := f.readFrame(raw)
+	f.lock.Unlock()
 
-	// 把本次数据追加到缓冲区
-	f.buf = append(f.buf, raw...)
+	f.notify(body, err)
+	return body, err
+}
 
-	// 先判断是否有足够的 header
-	if len(f.buf) < f.Hc.LengthFieldLength {
-		return nil, nil
+// ReadFrameInto 与 ReadFrame 相同，但把 body 拷贝到调用方提供的 dst 中，不做任何分配，适合定长帧的低延迟场景
+// - 取出一个完整帧时返回 body 的长度 n 和 ok=true，body 为 dst[:n]
+// - 数据不足时返回 ok=false
+// - dst 放不下 body 时返回 *ShortBufferError（包装 io.ErrShortBuffer），这个帧保留在缓冲区中，可以按 Need 换一个更大的 dst 再次调用（raw 传 nil）
+//
+// ReadFrameInto 不使用 ZeroCopy 和 BufferPool。配置了 CodecSelector 时还原 body 仍然需要分配，dst 太小时重试会重新还原一次
+func (f *Frame) ReadFrameInto(raw, dst []byte) (n int, ok bool, err error) {
+	f.lock.Lock()
+	rf, ok, err := f.nextFrame(raw)
+	if ok {
+		src := rf.body
+		if decoded, hasCodec, decodeErr := f.Hc.decodeBody(rf); decodeErr != nil {
+			f.consume(rf)
+			err, ok = decodeErr, false
+		} else if hasCodec {
+			src = decoded
+		}
+		switch {
+		case !ok:
+		case len(dst) < len(src):
+			err = &ShortBufferError{Need: len(src), Have: len(dst)}
+			ok = false
+		default:
+			n = copy(dst, src)
+			f.consume(rf)
+		}
 	}
+	f.lock.Unlock()
 
-	// 读取包体长度
-	bodyLen, err := f.Hc.Parse(f.buf[:f.Hc.LengthFieldLength])
-	if err != nil {
-		return nil, err
+	var body []byte
+	if ok {
+		body = dst[:n]
+	}
+	f.notify(body, err)
+	return n, ok, err
+}
+
+// readFrameCopy 与 ReadFrame 相同，withHeader 为 true 时同时返回帧头部（从 Magic 开始）的拷贝，
+// tap 不为 nil 时把完整的原始帧写入 tap，写入失败时返回这个错误（帧已经被消费）
+func (f *Frame) readFrameCopy(raw []byte, withHeader bool, tap io.Writer) (header, body []byte, err error) {
+	f.lock.Lock()
+	rf, body, err := f.readFrameRaw(raw)
+	if body != nil {
+		if withHeader {
+			header = append([]byte(nil), rf.header...)
+		}
+		if tap != nil {
+			if _, err = tap.Write(rf.wire); err != nil {
+				header, body = nil, nil
+			}
+		}
+	}
+	f.lock.Unlock()
+
+	f.notify(body, err)
+	return header, body, err
+}
+
+// headerEvent 一个等待 notify 通知的帧头部
+type headerEvent struct {
+	length int    // body 长度
+	raw    uint64 // 长度字段的原始值，hasRaw 为 false 时无意义
+	hasRaw bool
+}
+
+// recordHeader 记录缓冲区开头通过长度检查的头部，由 notify 在锁外触发 OnHeader / OnRawLength / OnLargeFrame，调用方需持有锁
+func (f *Frame) recordHeader(bodyLen, headerLen int) {
+	if f.Hc.OnHeader == nil && f.Hc.OnRawLength == nil && !f.Hc.isLargeFrame(bodyLen) {
+		return
+	}
+	h := headerEvent{length: bodyLen}
+	if f.Hc.OnRawLength != nil {
+		h.raw, h.hasRaw = f.Hc.rawLength(f.buf[:headerLen], f.byteOrder())
+	}
+	f.headers = append(f.headers, h)
+}
+
+// isLargeFrame 判断 body 长度为 bodyLen 的帧是否需要触发 OnLargeFrame
+func (hc *HeaderConfig) isLargeFrame(bodyLen int) bool {
+	return hc.OnLargeFrame != nil && hc.WarnFrameLength > 0 && bodyLen > hc.WarnFrameLength
+}
+
+// notify 在锁外触发 OnHeader / OnRawLength / OnLargeFrame / OnDropped / OnFrame / OnError 回调
+func (f *Frame) notify(body []byte, err error) {
+	if f.Hc.OnHeader != nil || f.Hc.OnRawLength != nil || f.Hc.OnLargeFrame != nil || f.Hc.OnDropped != nil {
+		f.lock.Lock()
+		headers, drops := f.headers, f.drops
+		f.headers, f.drops = nil, nil
+		f.lock.Unlock()
+		for _, h := range headers {
+			if f.Hc.OnRawLength != nil && h.hasRaw {
+				f.Hc.OnRawLength(h.raw)
+			}
+			if f.Hc.OnHeader != nil {
+				f.Hc.OnHeader(h.length)
+			}
+			if f.Hc.isLargeFrame(h.length) {
+				f.Hc.OnLargeFrame(h.length)
+			}
+		}
+		for _, size := range drops {
+			f.Hc.OnDropped(size)
+		}
+	}
+	if err != nil && f.Hc.OnError != nil {
+		f.Hc.OnError(err)
+	}
+	if body != nil && f.Hc.OnFrame != nil {
+		f.Hc.OnFrame(len(body))
+	}
+}
+
+// readFrame 是 ReadFrame 的实现，调用方需持有锁
+func (f *Frame) readFrame(raw []byte) ([]byte, error) {
+	_, body, err := f.readFrameRaw(raw)
+	return body, err
+}
+
+// readFrameRaw 与 readFrame 相同，同时返回这个帧在缓冲区中的原始数据
+// rf 中的切片引用内部缓冲区，只在下一次向缓冲区追加数据之前有效，调用方需持有锁
+func (f *Frame) readFrameRaw(raw []byte) (rf rawFrame, body []byte, err error) {
+	rf, ok, err := f.nextFrame(raw)
+	if !ok || err != nil {
+		return rawFrame{}, nil, err
+	}
+
+	if body, ok, err := f.Hc.decodeBody(rf); ok {
+		f.consume(rf)
+		if err != nil {
+			return rawFrame{}, nil, err
+		}
+		return rf, body, nil
+	}
+
+	body = rf.body
+	if !f.Hc.ZeroCopy {
+		body = f.Hc.getBuffer(len(rf.body))
+		copy(body, rf.body)
+	}
+	f.consume(rf)
+	return rf, body, nil
+}
+
+// parseHeaderResync 解析缓冲区开头的头部，开启 Resync 时 Magic 不匹配会先重新同步再解析一次，
+// 头部校验和不匹配时丢弃这个 Magic 重新同步，直到解析成功或数据不足
+func (f *Frame) parseHeaderResync() (bodyLen, headerLen int, ok bool, err error) {
+	bodyLen, headerLen, ok, err = f.parseHeader()
+	if err == ErrBadMagic && f.Hc.Resync {
+		f.skipToMagic()
+		bodyLen, headerLen, ok, err = f.parseHeader()
+	}
+	for err == ErrHeaderChecksumMismatch && f.Hc.Resync && len(f.Hc.Magic) > 0 {
+		f.skipCorruptHeader()
+		bodyLen, headerLen, ok, err = f.parseHeader()
+	}
+	return bodyLen, headerLen, ok, err
+}
+
+// skipDropping 从缓冲区中丢弃正在跳过的超长帧，返回是否已经丢完
+func (f *Frame) skipDropping() bool {
+	if f.dropping == 0 {
+		return true
+	}
+	n := len(f.buf)
+	if uint64(n) > f.dropping {
+		n = int(f.dropping)
+	}
+	f.buf = f.buf[n:]
+	f.dropping -= uint64(n)
+	f.consumed += uint64(n)
+	return f.dropping == 0
+}
+
+// rawFrame 缓冲区开头一个已经收齐的帧，各个切片都引用内部缓冲区
+type rawFrame struct {
+	wire    []byte // 完整的原始帧，包括头部、body、校验和与尾部
+	header  []byte
+	body    []byte // 按 InitialBytesToStrip 剥离之后返回给调用方的部分
+	trailer []byte
+	size    int // 帧在缓冲区中占用的总字节数
+}
+
+// nextFrame 把 raw 追加到缓冲区并找出开头的完整帧，但不消费它，数据不足时 ok 为 false
+// 校验和不匹配的帧会被直接丢弃。调用方需持有锁
+func (f *Frame) nextFrame(raw []byte) (rf rawFrame, ok bool, err error) {
+	if f.streaming {
+		// 本次输入仍然属于正在分块返回的 body，保留下来交给之后的 ReadFrameChunk
+		f.buf = append(f.buf, raw...)
+		return rawFrame{}, false, errors.New("frame body is being streamed, use ReadFrameChunk")
+	}
+	if err := f.appendInput(raw); err != nil {
+		return rawFrame{}, false, err
 	}
 
-	//f.buf = f.buf[f.Hc.LengthFieldLength:]
+	for {
+		rf, ok, err = f.nextBuffered()
+		if !ok || err != nil {
+			return rawFrame{}, false, err
+		}
+		if !f.Hc.SkipEmptyFrames || len(rf.wire)-len(rf.header)-len(rf.trailer) > f.Hc.ChecksumLength {
+			break
+		}
+		// 空帧直接跳过，不计入 RateLimiter
+		f.drop(rf.size)
+	}
+
+	if f.Hc.RateLimiter != nil && !f.Hc.RateLimiter.Allow() {
+		if f.Hc.RateLimitDrop {
+			f.drop(rf.size)
+		}
+		return rawFrame{}, false, ErrRateLimited
+	}
+	return rf, true, nil
+}
 
-	// 总包长度 = header + body
-	totalLen := f.Hc.LengthFieldLength + bodyLen
+// nextBuffered 找出缓冲区开头的完整帧，是 nextFrame 在追加输入之后的部分，调用方需持有锁
+func (f *Frame) nextBuffered() (rf rawFrame, ok bool, err error) {
+	// 读取包体长度，header 不完整时等待下次
+	bodyLen, headerLen, ok, err := f.nextHeader()
+	if err != nil {
+		return rawFrame{}, false, err
+	}
+	if !ok {
+		return rawFrame{}, false, nil
+	}
+
+	// 总包长度 = header + body + trailer
+	// 32 位平台上一个接近 MaxInt 的长度字段加上头部长度就会溢出成负数，切片前必须拦下来
+	trailerStart := headerLen + bodyLen
+	if bodyLen < 0 || trailerStart < headerLen || trailerStart > math.MaxInt-f.Hc.TrailerLength {
+		return rawFrame{}, false, fmt.Errorf("%w: header %d + body %d", ErrLengthOverflow, headerLen, bodyLen)
+	}
+	totalLen := trailerStart + f.Hc.TrailerLength
+	if !f.committed {
+		f.committed = true
+		f.recordHeader(bodyLen, headerLen)
+	}
 
 	// 判断数据是否足够
 	if len(f.buf) < totalLen {
-		return nil, nil // 数据不够，等待下次
+		// 已经知道整个帧的长度，一次性扩容，避免由很多次小读取拼成大包时反复 append 扩容
+		// 没有配置 MaxFrameLength 时长度字段不可信，最多预分配 maxPrealloc，防止一个恶意的头部直接占满内存
+		want := totalLen
+		if f.maxFrameLength() == 0 {
+			want = min(want, maxPrealloc)
+		}
+		if cap(f.buf) < want && !f.split {
+			buf := make([]byte, len(f.buf), want)
+			copy(buf, f.buf)
+			f.buf = buf
+		}
+		return rawFrame{}, false, nil // 数据不够，等待下次
 	}
 
 	// 拿出一个完整包
-	body := f.buf[f.Hc.LengthFieldLength:totalLen]
+	rf, corrupt, err := f.Hc.splitFrame(f.buf[:totalLen], headerLen, trailerStart)
+	if corrupt {
+		// 丢弃损坏的帧，调用方可以继续读取后续数据
+		f.drop(totalLen)
+	}
+	if err != nil {
+		return rawFrame{}, false, err
+	}
+	return rf, true, nil
+}
+
+// drop 丢弃缓冲区开头 size 字节的帧，调用方需持有锁
+func (f *Frame) drop(size int) {
+	f.buf = f.buf[size:]
+	f.consumed += uint64(size)
+	f.committed = false
+}
 
-	// 更新缓冲区，丢掉已消费的部分
-	f.buf = f.buf[totalLen:]
+// splitFrame 把收齐的完整帧 wire 按头部、body、尾部拆开并校验校验和，trailerStart 为尾部的起始位置
+// 校验和不匹配时 corrupt 为 true，这个帧应被丢弃
+func (hc *HeaderConfig) splitFrame(wire []byte, headerLen, trailerStart int) (rf rawFrame, corrupt bool, err error) {
+	frame, trailer := wire[:trailerStart], wire[trailerStart:]
 
-	return body, nil
+	if hc.ChecksumLength > 0 {
+		body, err := hc.verifyChecksum(frame[headerLen:])
+		if err != nil {
+			return rawFrame{}, true, err
+		}
+		frame = frame[:headerLen+len(body)]
+	}
+
+	strip, err := hc.stripLen(headerLen)
+	if err != nil {
+		return rawFrame{}, false, err
+	}
+	if strip > len(frame) {
+		return rawFrame{}, false, errors.New("InitialBytesToStrip exceeds frame length")
+	}
+
+	rf = rawFrame{wire: wire, header: frame[:headerLen], body: frame[strip:], trailer: trailer, size: len(wire)}
+	if hc.ReturnFullFrame {
+		rf.body = rf.wire
+	}
+	return rf, false, nil
+}
+
+// appendInput 把本次数据追加到缓冲区，并检查缓冲区中还有数据时头部配置是否被修改过，调用方需持有锁
+func (f *Frame) appendInput(raw []byte) error {
+	hadData := len(f.buf) > 0 || f.streaming
+	if len(raw) > 0 {
+		f.buf = append(f.buf, raw...)
+		f.lastInput = time.Now()
+	}
+
+	layout := f.Hc.headerLayout()
+	if hadData && f.hasLayout && !f.layout.equal(layout) {
+		return ErrConfigChangedMidFrame
+	}
+	f.layout, f.hasLayout = layout, true
+	return nil
+}
+
+// nextHeader 解析缓冲区开头的头部，开启 DropOversized 时先丢弃超长帧，数据不足时 ok 为 false，调用方需持有锁
+func (f *Frame) nextHeader() (bodyLen, headerLen int, ok bool, err error) {
+	// 还在丢弃之前的超长帧时先把它的剩余部分丢完
+	if !f.skipDropping() {
+		return 0, 0, false, nil
+	}
+
+	bodyLen, headerLen, ok, err = f.parseHeaderResync()
+	for f.Hc.DropOversized && err == ErrFrameTooLarge {
+		f.dropping = uint64(headerLen) + uint64(bodyLen) + uint64(f.Hc.TrailerLength)
+		if f.Hc.OnDropped != nil {
+			f.drops = append(f.drops, bodyLen)
+		}
+		if !f.skipDropping() {
+			return 0, 0, false, nil
+		}
+		bodyLen, headerLen, ok, err = f.parseHeaderResync()
+	}
+	return bodyLen, headerLen, ok, err
+}
+
+// consume 从缓冲区中移除 nextFrame 找到的帧，调用方需持有锁
+func (f *Frame) consume(rf rawFrame) {
+	if f.Hc.TrailerLength > 0 {
+		f.trailer = append(f.trailer[:0], rf.trailer...)
+	}
+
+	// 更新缓冲区，丢掉已消费的部分；全部消费完时从头复用底层数组
+	buf := f.buf
+	f.buf = f.buf[rf.size:]
+	f.decoded++
+	f.consumed += uint64(rf.size)
+	f.committed = false
+	if len(f.buf) == 0 {
+		f.buf = buf[:0]
+	}
+	if f.Hc.ShrinkThreshold > 0 && cap(f.buf) > f.Hc.ShrinkThreshold {
+		f.compact()
+	}
+}
+
+// buffered 返回缓冲区中尚未消费的字节数
+func (f *Frame) buffered() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.buf)
+}
+
+// SkipToMagic 丢弃缓冲区中下一个 Magic 出现之前的数据，用于在 ErrBadMagic 之后重新同步，返回丢弃的字节数
+// - 缓冲区开头已经是 Magic 时不丢弃任何数据
+// - 找不到 Magic 时保留末尾可能是半个 Magic 的字节，其余全部丢弃
+// - 未配置 Magic 时不做任何事
+func (f *Frame) SkipToMagic() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.skipToMagic()
+}
+
+func (f *Frame) skipToMagic() int {
+	magic := f.Hc.Magic
+	if len(magic) == 0 {
+		return 0
+	}
+
+	// 从下标 1 开始找，避免停在当前这个错误的位置上
+	idx := 0
+	if !bytes.HasPrefix(f.buf, magic) && !bytes.HasPrefix(magic, f.buf) {
+		idx = len(f.buf)
+		for i := 1; i < len(f.buf); i++ {
+			if bytes.HasPrefix(magic, f.buf[i:]) || bytes.HasPrefix(f.buf[i:], magic) {
+				idx = i
+				break
+			}
+		}
+	}
+
+	if idx > 0 {
+		f.committed = false
+	}
+	f.buf = f.buf[idx:]
+	f.resynced += uint64(idx)
+	f.consumed += uint64(idx)
+	return idx
+}
+
+// Recover 在 ReadFrame 返回头部错误（ErrFrameTooLarge、ErrBadMagic、ErrFrameTooSmall 等）之后丢弃出错的帧，
+// 让之后的 ReadFrame 从下一个帧继续。不调用 Recover 时出错的帧一直留在缓冲区开头，
+// 之后每次 ReadFrame 都返回同样的错误（新的输入仍然追加到缓冲区，不会丢失，也不会被错误地解析），
+// 调用方也可以选择提高上限（SetMaxFrameLength）后重试，或者直接关闭连接
+// - 配置了 Magic 时丢弃到下一个 Magic 为止（不信任出错的头部中的长度），丢弃的字节计入 ResyncedBytes
+// - 没有 Magic 时只能从 ErrFrameTooLarge 恢复：按头部中的长度丢弃整个帧（包括还没有到达的部分），与 DropOversized 相同
+// - 其他错误没有可以重新同步的位置，返回包装了原错误的错误，缓冲区保持不变
+// - 缓冲区开头没有错误时不做任何事，返回 nil
+func (f *Frame) Recover() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.streaming || f.dropping > 0 || f.committed {
+		return nil
+	}
+	bodyLen, headerLen, _, err := f.parseHeader()
+	switch {
+	case err == nil:
+		return nil
+	case len(f.Hc.Magic) > 0:
+		f.skipCorruptHeader()
+	case errors.Is(err, ErrFrameTooLarge):
+		f.dropping = uint64(headerLen) + uint64(bodyLen) + uint64(f.Hc.TrailerLength)
+		f.skipDropping()
+	default:
+		return fmt.Errorf("cannot recover without Magic: %w", err)
+	}
+	return nil
+}
+
+// ResyncedBytes 返回这个 Frame 为了重新同步 Magic（Resync 或 SkipToMagic）累计丢弃的字节数，可用于监控链路质量
+func (f *Frame) ResyncedBytes() uint64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.resynced
+}
+
+// Trailer 返回最近一次取出的帧的尾部（TrailerLength 字节）的拷贝，还没有取出过帧或未配置 TrailerLength 时返回 nil
+// 多个 goroutine 同时读取同一个 Frame 时无法确定尾部属于哪个帧
+func (f *Frame) Trailer() []byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.trailer == nil {
+		return nil
+	}
+	return append([]byte(nil), f.trailer...)
+}
+
+// DebugDump 以 hexdump -C 的格式返回缓冲区中尚未消费的数据，最多 DebugDumpLimit 字节，便于排查对端发来的错误头部
+// 只读取缓冲区，不影响解码
+func (f *Frame) DebugDump() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	limit := f.Hc.DebugDumpLimit
+	if limit == 0 {
+		limit = defaultDebugDumpLimit
+	}
+	n := min(len(f.buf), limit)
+
+	dump := fmt.Sprintf("%d bytes buffered\n", len(f.buf)) + hex.Dump(f.buf[:n])
+	if n < len(f.buf) {
+		dump += fmt.Sprintf("... %d more bytes\n", len(f.buf)-n)
+	}
+	return dump
+}
+
+// FrameStats Frame 的累计统计，见 Frame.Stats
+type FrameStats struct {
+	FramesDecoded uint64 // 成功取出的帧数
+	BytesConsumed uint64 // 从缓冲区移除的字节数，包括取出的帧、校验失败被丢弃的帧和重新同步丢弃的字节
+	BytesBuffered int    // 当前缓冲区中尚未消费的字节数
+}
+
+// Stats 返回这个 Frame 创建以来的累计统计，适合定期轮询，比 OnFrame 回调开销更小
+// 计数器为 uint64，即使每秒处理 10GB 也需要几十年才会回绕
+func (f *Frame) Stats() FrameStats {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return FrameStats{
+		FramesDecoded: f.decoded,
+		BytesConsumed: f.consumed,
+		BytesBuffered: len(f.buf),
+	}
+}
+
+// Compact 在缓冲区容量超过剩余数据长度的 4 倍时重新分配一个刚好容纳剩余数据的缓冲区，返回是否发生了收缩
+// 之前 ReadFrame 返回的帧仍然引用旧的底层数组，不受影响
+func (f *Frame) Compact() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.compact()
+}
+
+func (f *Frame) compact() bool {
+	if cap(f.buf) <= 4*len(f.buf) {
+		return false
+	}
+	buf := make([]byte, len(f.buf))
+	copy(buf, f.buf)
+	f.buf = buf
+	return true
+}
+
+// TrimToFit 把缓冲区的容量收缩到恰好容纳剩余数据，缓冲区为空时完全释放（ReadFromConn 的读缓冲区也一并释放），
+// 适合连接池在连接空闲时回收偶尔收到大包后长期占用的内存。
+// 与 Compact 不同，TrimToFit 总是重新分配，代价是之后收到数据时需要重新分配缓冲区。
+// 之前 ReadFrame 返回的帧仍然引用旧的底层数组，不受影响
+func (f *Frame) TrimToFit() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.scratch = nil
+	if len(f.buf) == 0 {
+		f.buf = nil
+		return
+	}
+	if cap(f.buf) > len(f.buf) {
+		buf := make([]byte, len(f.buf))
+		copy(buf, f.buf)
+		f.buf = buf
+	}
+}
+
+// Snapshot 返回缓冲区中尚未消费的数据的拷贝，配合 Restore 可以把 Frame 退回到同一个状态，
+// 用于模糊测试和性质测试（同一段输入按不同的分块方式输入，结果应该相同）
+func (f *Frame) Snapshot() []byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]byte{}, f.buf...)
+}
+
+// Restore 用 buf 的拷贝替换缓冲区，之后的 ReadFrame 把 buf 当作刚刚到达、按当前配置解析的数据
+// - 正在进行的 DropOversized 丢弃、StreamBody 分块读取和已经通过长度检查的头部都会被清除，缓冲区开头重新作为帧边界
+// - 不修改 Stats、ResyncedBytes 等累计的统计，也不修改 AutoByteOrder 锁定的字节序
+// - 不会保留对 buf 的引用
+func (f *Frame) Restore(buf []byte) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.buf = append(f.buf[:0], buf...)
+	f.lastInput = time.Now()
+	f.layout, f.hasLayout = f.Hc.headerLayout(), true
+	f.committed = false
+	f.dropping = 0
+	f.streaming, f.streamLen, f.remaining = false, 0, 0
+}
+
+// DiscardStalePartial 在缓冲区中的不完整帧超过 maxAge 没有新数据到达时清空缓冲区并返回 true，否则返回 false
+// 用于承载突发短消息的长连接：链路故障留下的半个帧会一直留在缓冲区中，之后到达的新帧被拼接在它后面而错位，
+// 应用在检测到间隔（例如定时器或者两次突发之间）时调用它丢弃旧数据、从下一个字节开始重新作为帧边界
+// - 缓冲区为空，或者开头是一个已经完整、只是还没有被取走的帧时返回 false，不丢弃任何数据
+// - 正在进行的 DropOversized 丢弃和 StreamBody 分块读取同样被视为不完整的帧，清除后调用方不会再收到这个 body 剩余的分块
+// - 丢弃的字节计入 Stats 中的 BytesConsumed
+func (f *Frame) DiscardStalePartial(maxAge time.Duration) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if len(f.buf) == 0 && !f.streaming && f.dropping == 0 {
+		return false
+	}
+	if time.Since(f.lastInput) <= maxAge {
+		return false
+	}
+	if !f.streaming && f.dropping == 0 {
+		if length, ok := f.pendingFrameLength(); ok && length <= len(f.buf) {
+			return false
+		}
+	}
+
+	f.consumed += uint64(len(f.buf))
+	f.buf = f.buf[:0]
+	f.committed = false
+	f.dropping = 0
+	f.streaming, f.streamLen, f.remaining = false, 0, 0
+	return true
+}
+
+// PeekLength 在不消费任何数据的情况下返回下一个帧的 body 长度（已应用 LengthAdjustment 等修正）
+// - 头部还没有收齐时 ready 为 false
+// - 开启 AutoByteOrder 时与 ReadFrame 一样会尝试相反的字节序，但不会锁定字节序
+// - 头部错误（Magic 不匹配、长度超限等）通过 err 返回
+func (f *Frame) PeekLength() (length int, ready bool, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	bodyLen, _, _, ok, err := f.peekHeader()
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	return bodyLen, true, nil
+}
+
+// Feed 输入一次读到的数据，取出其中所有已经完整的帧，适合与环形缓冲区等需要流量控制的数据源配合
+// - consumed 为 raw 中被接收的字节数，未配置 HighWaterMark 时总是 len(raw)
+// - 配置了 HighWaterMark 时边接收边取帧，缓冲区中的数据不会超过 HighWaterMark；缓冲区满了仍凑不出完整帧时返回 ErrHighWaterMark，此时 consumed < len(raw)
+// - 遇到错误时停止并返回之前取出的帧和这个错误，与 ReadFrame 一样可以根据错误决定是否继续
+// - 配置了 ZeroCopy 时返回的帧仍然会被拷贝，因为一次调用中取出多个帧时内部缓冲区会被后续输入覆盖
+//
+// 调用方应按 consumed 推进数据源，未被接收的部分需要在下次调用时重新输入
+func (f *Frame) Feed(raw []byte) (frames [][]byte, consumed int, err error) {
+	f.lock.Lock()
+	for {
+		n := len(raw) - consumed
+		if mark := f.Hc.HighWaterMark; mark > 0 {
+			n = min(n, max(mark-len(f.buf), 0))
+		}
+
+		var body []byte
+		body, err = f.readFrame(raw[consumed : consumed+n])
+		consumed += n
+		if err != nil {
+			break
+		}
+		if body == nil {
+			if consumed == len(raw) {
+				break
+			}
+			// 只有缓冲区已经满了、这一轮又没能接收任何输入时才是真的卡住了；
+			// DropOversized、Resync、SkipEmptyFrames 丢弃数据后缓冲区变小，可以继续接收
+			if mark := f.Hc.HighWaterMark; n == 0 && len(f.buf) >= mark {
+				err = ErrHighWaterMark
+				break
+			}
+			continue
+		}
+		if f.Hc.ZeroCopy {
+			// ZeroCopy 的 body 引用内部缓冲区，缓冲区取空后下一段输入会复用同一块内存，必须在继续接收之前拷贝
+			body = append([]byte{}, body...)
+		}
+		frames = append(frames, body)
+	}
+	f.lock.Unlock()
+
+	for _, body := range frames {
+		f.notify(body, nil)
+	}
+	if err != nil {
+		f.notify(nil, err)
+	}
+	return frames, consumed, err
+}
+
+// FeedTo 输入一次读到的数据，对其中每个完整的帧调用一次 fn，适合纯推送式的处理流程，省去反复调用 ReadFrame(nil) 的循环
+// - raw 与 ReadFrame 一样全部追加到缓冲区（不受 HighWaterMark 限制，需要流量控制时使用 Feed）
+// - fn 在锁外调用，可以在 fn 中调用这个 Frame 的其他方法
+// - fn 返回错误时立即停止并返回这个错误，之后的帧保留在缓冲区中，下次调用 FeedTo 或 ReadFrame 时继续取出
+// - 解码出错时停止并返回这个错误，与 ReadFrame 一样可以根据错误决定是否继续
+//
+// body 只在 fn 执行期间有效（ZeroCopy 时引用内部缓冲区，配置了 BufferPool 时可能被复用），fn 需要保留时必须自行拷贝
+func (f *Frame) FeedTo(raw []byte, fn func(body []byte) error) error {
+	for {
+		f.lock.Lock()
+		body, err := f.readFrame(raw)
+		f.lock.Unlock()
+		raw = nil
+
+		f.notify(body, err)
+		if err != nil || body == nil {
+			return err
+		}
+		if err := fn(body); err != nil {
+			return err
+		}
+	}
+}
+
+// PendingFrameLength 返回缓冲区开头正在等待的帧在线路上的总长度（头部 + body + 尾部），不消费任何数据
+// - 头部还没有收齐（或缓冲区为空）时 haveHeader 为 false
+// - 还缺少的字节数为 length - Stats().BytesBuffered，配合计时器可以发现对端声明了长度之后就不再发送数据的连接
+// - StreamBody 分块返回期间 length 为这个帧剩余部分的长度，计算方式相同
+func (f *Frame) PendingFrameLength() (length int, haveHeader bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.pendingFrameLength()
+}
+
+// pendingFrameLength 是 PendingFrameLength 的实现，调用方需持有锁
+func (f *Frame) pendingFrameLength() (length int, haveHeader bool) {
+	if f.streaming {
+		return len(f.buf) + int(f.remaining), true
+	}
+	if f.dropping > 0 {
+		return 0, false
+	}
+	bodyLen, headerLen, _, ok, err := f.peekHeader()
+	if err != nil || !ok {
+		return 0, false
+	}
+	trailerStart := headerLen + bodyLen
+	if trailerStart < headerLen || trailerStart > math.MaxInt-f.Hc.TrailerLength {
+		return 0, false
+	}
+	return trailerStart + f.Hc.TrailerLength, true
+}
+
+// DrainAll 不输入新数据，取出缓冲区中所有已经完整的帧，并返回剩余不完整数据的拷贝
+// 用于对端关闭连接时把剩余的帧处理完；len(partial) > 0 说明最后一个帧被截断了。
+// 解析过程中遇到错误时停止，出错位置之后的数据都作为 partial 返回。partial 仍然保留在缓冲区中
+func (f *Frame) DrainAll() (frames [][]byte, partial []byte) {
+	f.lock.Lock()
+	var errs []error
+	for {
+		body, err := f.readFrame(nil)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if body == nil {
+			break
+		}
+		frames = append(frames, body)
+	}
+	if len(f.buf) > 0 {
+		partial = make([]byte, len(f.buf))
+		copy(partial, f.buf)
+	}
+	f.lock.Unlock()
+
+	for _, body := range frames {
+		f.notify(body, nil)
+	}
+	for _, err := range errs {
+		f.notify(nil, err)
+	}
+	return frames, partial
+}
+
+// Release 把 ReadFrame 返回的帧归还到 BufferPool，调用之后不能再使用 body
+// 未配置 BufferPool 或开启了 ZeroCopy 时不做任何事
+func (f *Frame) Release(body []byte) {
+	if f.Hc.BufferPool == nil || f.Hc.ZeroCopy || body == nil {
+		return
+	}
+	body = body[:0]
+	f.Hc.BufferPool.Put(&body)
+}
+
+// SplitFrames 把已经完整读入内存的数据（例如抓包文件）一次性切分成帧，返回所有完整帧的 body 和末尾不完整的数据
+// - 与逐次调用 ReadFrame 使用相同的头部解析和长度检查，但不需要维护一个 Frame
+// - 遇到错误时停止，返回出错之前的帧，partial 为出错位置（校验和错误时为损坏的帧之后）开始的剩余数据
+// - partial 和开启 ZeroCopy 时的 body 都是 data 的子切片，data 不会被修改；不触发 HeaderConfig 中的回调
+func SplitFrames(data []byte, hc *HeaderConfig) (frames [][]byte, partial []byte, err error) {
+	if hc == nil {
+		return nil, nil, errors.New("nil HeaderConfig")
+	}
+
+	f := &Frame{Hc: hc, buf: data[:len(data):len(data)], split: true}
+	for {
+		body, err := f.readFrame(nil)
+		if err != nil {
+			return frames, data[f.consumed:], err
+		}
+		if body == nil {
+			return frames, data[f.consumed:], nil
+		}
+		frames = append(frames, body)
+	}
 }
@@ -1,8 +1,13 @@
 package frame
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"sync"
 	"testing"
 	"time"
@@ -129,7 +134,7 @@ func TestHeaderConfig_Parse(t *testing.T) {
 			},
 			header:        []byte{0x00, 0x00, 0x01},
 			expectedError: true,
-			errorMessage:  "unsupported LengthFieldLength, only 2 or 4",
+			errorMessage:  "unsupported LengthFieldLength, only 2, 4 or 8",
 		},
 		{
 			name: "不支持的长度字段长度-1字节",
@@ -139,7 +144,7 @@ func TestHeaderConfig_Parse(t *testing.T) {
 			},
 			header:        []byte{0x10},
 			expectedError: true,
-			errorMessage:  "unsupported LengthFieldLength, only 2 or 4",
+			errorMessage:  "unsupported LengthFieldLength, only 2, 4 or 8",
 		},
 	}
 
@@ -165,6 +170,115 @@ func TestHeaderConfig_Parse(t *testing.T) {
 	}
 }
 
+// TestHeaderConfig_Validate 测试配置校验
+func TestHeaderConfig_Validate(t *testing.T) {
+	valid := func() *HeaderConfig {
+		return &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	}
+
+	tests := []struct {
+		name         string
+		modify       func(hc *HeaderConfig)
+		errorMessage string
+	}{
+		{name: "合法配置", modify: func(hc *HeaderConfig) {}},
+		{name: "合法的varint配置", modify: func(hc *HeaderConfig) {
+			hc.ByteOrder, hc.LengthFieldLength, hc.LengthEncoding = nil, 0, LengthVarint
+		}},
+		{name: "不支持的长度字段长度", modify: func(hc *HeaderConfig) { hc.LengthFieldLength = 3 },
+			errorMessage: "unsupported LengthFieldLength, only 2, 4 or 8"},
+		{name: "缺少字节序", modify: func(hc *HeaderConfig) { hc.ByteOrder = nil },
+			errorMessage: "ByteOrder is required"},
+		{name: "未知的长度编码", modify: func(hc *HeaderConfig) { hc.LengthEncoding = 99 },
+			errorMessage: "unsupported LengthEncoding"},
+		{name: "结束符是数字", modify: func(hc *HeaderConfig) { hc.LengthEncoding, hc.LengthTerminator = LengthASCIIDecimal, '1' },
+			errorMessage: "LengthTerminator must not be a digit"},
+		{name: "两种长度修正同时设置", modify: func(hc *HeaderConfig) { hc.LengthIncludesHeader, hc.LengthAdjustment = true, 1 },
+			errorMessage: "LengthIncludesHeader and LengthAdjustment are mutually exclusive"},
+		{name: "负的最大长度", modify: func(hc *HeaderConfig) { hc.MaxFrameLength = -1 },
+			errorMessage: "MaxFrameLength must not be negative"},
+		{name: "AutoByteOrder缺少最大长度", modify: func(hc *HeaderConfig) { hc.AutoByteOrder = true },
+			errorMessage: "AutoByteOrder requires MaxFrameLength"},
+		{name: "非法的剥离长度", modify: func(hc *HeaderConfig) { hc.InitialBytesToStrip = -2 },
+			errorMessage: "invalid InitialBytesToStrip"},
+		{name: "负的收缩阈值", modify: func(hc *HeaderConfig) { hc.ShrinkThreshold = -1 },
+			errorMessage: "ShrinkThreshold must not be negative"},
+		{name: "不支持的校验和长度", modify: func(hc *HeaderConfig) { hc.ChecksumLength = 3 },
+			errorMessage: "unsupported ChecksumLength, only 1, 2 or 4"},
+		{name: "缺少校验和函数", modify: func(hc *HeaderConfig) { hc.ChecksumLength = 4 },
+			errorMessage: "ChecksumFunc is required when ChecksumLength is set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := valid()
+			tt.modify(hc)
+			err := hc.Validate()
+			if tt.errorMessage == "" {
+				if err != nil {
+					t.Errorf("不期望出现错误，但出现了错误: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("期望出现错误，但没有错误")
+			} else if err.Error() != tt.errorMessage {
+				t.Errorf("错误信息不匹配，期望: %s, 实际: %s", tt.errorMessage, err.Error())
+			}
+		})
+	}
+}
+
+// TestNewFrame 测试构造函数
+func TestNewFrame(t *testing.T) {
+	if _, err := NewFrame(nil); err == nil {
+		t.Errorf("nil 配置应返回错误")
+	}
+	if _, err := NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 3}); err == nil {
+		t.Errorf("非法配置应返回错误")
+	}
+
+	frame, err := NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2})
+	if err != nil {
+		t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+	}
+	result, err := frame.ReadFrame([]byte{0x00, 0x02, 'h', 'i'})
+	if err != nil || string(result) != "hi" {
+		t.Errorf("期望读到 hi，实际: %q, %v", result, err)
+	}
+}
+
+// TestHeaderConfig_ParseUint64 测试返回原始无符号长度
+func TestHeaderConfig_ParseUint64(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 4}
+
+	v, err := config.ParseUint64([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	if err != nil || v != 0xFFFFFFFF {
+		t.Errorf("期望 0xFFFFFFFF，实际: %#x, %v", v, err)
+	}
+
+	// Parse 不会返回负数：要么在 int 范围内，要么返回 ErrLengthOverflow
+	n, err := config.Parse([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	if err != nil {
+		if !errors.Is(err, ErrLengthOverflow) {
+			t.Errorf("期望 ErrLengthOverflow，实际: %v", err)
+		}
+	} else if n < 0 || uint64(n) != v {
+		t.Errorf("Parse 结果不正确: %d", n)
+	}
+
+	if _, err := config.ParseUint64([]byte{0x00}); err == nil || err.Error() != "header too short" {
+		t.Errorf("期望 header too short 错误，实际: %v", err)
+	}
+
+	// varint 超出 int 范围
+	varint := &HeaderConfig{LengthEncoding: LengthVarint}
+	frame := &Frame{Hc: varint}
+	if _, err := frame.ReadFrame(binary.AppendUvarint(nil, math.MaxUint64)); !errors.Is(err, ErrLengthOverflow) {
+		t.Errorf("期望 ErrLengthOverflow，实际: %v", err)
+	}
+}
+
 // TestFrame_ReadFrame 测试数据包读取功能
 func TestFrame_ReadFrame(t *testing.T) {
 	tests := []struct {
@@ -324,7 +438,7 @@ func TestFrame_ReadFrame(t *testing.T) {
 			},
 			expectedFrames: nil,
 			expectedError:  true,
-			errorMessage:   "unsupported LengthFieldLength, only 2 or 4",
+			errorMessage:   "unsupported LengthFieldLength, only 2, 4 or 8",
 		},
 	}
 
@@ -624,61 +738,2356 @@ func TestFrame_ReadFrame_DataIntegrity(t *testing.T) {
 	}
 }
 
-// 辅助函数：比较字节切片
-func bytesEqual(a, b []byte) bool {
-	if len(a) != len(b) {
-		return false
+// TestFrame_ReadFrame_InitialBytesToStrip 测试剥离帧头部字节
+func TestFrame_ReadFrame_InitialBytesToStrip(t *testing.T) {
+	packet := []byte{0x00, 0x03, 'a', 'b', 'c'}
+
+	tests := []struct {
+		name          string
+		strip         int
+		expected      []byte
+		expectedError bool
+	}{
+		{name: "默认剥离长度字段", strip: 0, expected: []byte{'a', 'b', 'c'}},
+		{name: "显式剥离长度字段", strip: 2, expected: []byte{'a', 'b', 'c'}},
+		{name: "不剥离返回完整帧", strip: StripNone, expected: packet},
+		{name: "剥离部分body", strip: 3, expected: []byte{'b', 'c'}},
+		{name: "剥离整个帧", strip: 5, expected: []byte{}},
+		{name: "剥离长度超过帧长度", strip: 6, expectedError: true},
+		{name: "非法的负数", strip: -2, expectedError: true},
 	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := &Frame{
+				Hc: &HeaderConfig{
+					ByteOrder:           binary.BigEndian,
+					LengthFieldLength:   2,
+					InitialBytesToStrip: tt.strip,
+				},
+			}
+
+			result, err := frame.ReadFrame(packet)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("期望出现错误，但没有错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+			}
+			if !bytesEqual(result, tt.expected) {
+				t.Errorf("包内容不匹配，期望: %v, 实际: %v", tt.expected, result)
+			}
+			if len(frame.buf) != 0 {
+				t.Errorf("缓冲区应越过完整帧，剩余长度: %d", len(frame.buf))
+			}
+		})
+	}
+}
+
+// TestFrame_ReadFrame_Varint 测试 varint 编码的长度字段
+func TestFrame_ReadFrame_Varint(t *testing.T) {
+	config := &HeaderConfig{LengthEncoding: LengthVarint}
+
+	t.Run("单字节varint", func(t *testing.T) {
+		frame := &Frame{Hc: config}
+		result, err := frame.ReadFrame([]byte{0x03, 'a', 'b', 'c'})
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		if !bytesEqual(result, []byte{'a', 'b', 'c'}) {
+			t.Errorf("包内容不匹配，实际: %v", result)
+		}
+	})
+
+	t.Run("多字节varint分包", func(t *testing.T) {
+		body := make([]byte, 300)
+		for i := range body {
+			body[i] = byte(i)
+		}
+		packet := binary.AppendUvarint(nil, uint64(len(body)))
+		packet = append(packet, body...)
+
+		frame := &Frame{Hc: config}
+		// 先只给 varint 的第一个字节，continuation 位仍为 1
+		result, err := frame.ReadFrame(packet[:1])
+		if err != nil || result != nil {
+			t.Fatalf("varint 不完整时应返回 (nil, nil)，实际: %v, %v", result, err)
+		}
+		result, err = frame.ReadFrame(packet[1:])
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		if !bytesEqual(result, body) {
+			t.Errorf("包内容不匹配")
+		}
+	})
+
+	t.Run("超长varint", func(t *testing.T) {
+		frame := &Frame{Hc: config}
+		overlong := make([]byte, binary.MaxVarintLen64)
+		for i := range overlong {
+			overlong[i] = 0x80
+		}
+		_, err := frame.ReadFrame(overlong)
+		if err == nil || err.Error() != "varint length overflow" {
+			t.Errorf("期望 varint length overflow 错误，实际: %v", err)
 		}
+	})
+
+	t.Run("StripNone返回varint头部", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{LengthEncoding: LengthVarint, InitialBytesToStrip: StripNone}}
+		packet := []byte{0x02, 'h', 'i'}
+		result, err := frame.ReadFrame(packet)
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		if !bytesEqual(result, packet) {
+			t.Errorf("包内容不匹配，实际: %v", result)
+		}
+	})
+}
+
+// TestFrame_ReadFrame_Magic 测试帧开头的 Magic 校验
+func TestFrame_ReadFrame_Magic(t *testing.T) {
+	newFrame := func() *Frame {
+		return &Frame{Hc: &HeaderConfig{
+			ByteOrder:         binary.BigEndian,
+			LengthFieldLength: 2,
+			Magic:             []byte{0xCA, 0xFE},
+		}}
 	}
-	return true
+
+	t.Run("Magic匹配", func(t *testing.T) {
+		frame := newFrame()
+		result, err := frame.ReadFrame([]byte{0xCA, 0xFE, 0x00, 0x02, 'h', 'i'})
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		if string(result) != "hi" {
+			t.Errorf("包内容不匹配，实际: %q", result)
+		}
+	})
+
+	t.Run("Magic分包", func(t *testing.T) {
+		frame := newFrame()
+		result, err := frame.ReadFrame([]byte{0xCA})
+		if err != nil || result != nil {
+			t.Fatalf("Magic 不完整时应返回 (nil, nil)，实际: %v, %v", result, err)
+		}
+		result, err = frame.ReadFrame([]byte{0xFE, 0x00, 0x02, 'h', 'i'})
+		if err != nil || string(result) != "hi" {
+			t.Errorf("期望读到 hi，实际: %q, %v", result, err)
+		}
+	})
+
+	t.Run("Magic不匹配不消费数据", func(t *testing.T) {
+		frame := newFrame()
+		input := []byte{'G', 'E', 'T', ' ', '/'}
+		for i := 0; i < 2; i++ {
+			_, err := frame.ReadFrame(input)
+			if !errors.Is(err, ErrBadMagic) {
+				t.Fatalf("期望 ErrBadMagic，实际: %v", err)
+			}
+			input = nil
+		}
+		if len(frame.buf) != 5 {
+			t.Errorf("不应消费任何数据，剩余长度: %d", len(frame.buf))
+		}
+	})
+
+	t.Run("部分Magic不匹配提前报错", func(t *testing.T) {
+		frame := newFrame()
+		if _, err := frame.ReadFrame([]byte{0xCB}); !errors.Is(err, ErrBadMagic) {
+			t.Errorf("期望 ErrBadMagic，实际: %v", err)
+		}
+	})
+
+	t.Run("SkipToMagic重新同步", func(t *testing.T) {
+		frame := newFrame()
+		_, err := frame.ReadFrame([]byte{0x01, 0xCA, 0x02, 0xCA, 0xFE, 0x00, 0x02, 'h', 'i'})
+		if !errors.Is(err, ErrBadMagic) {
+			t.Fatalf("期望 ErrBadMagic，实际: %v", err)
+		}
+		if n := frame.SkipToMagic(); n != 3 {
+			t.Errorf("期望丢弃 3 字节，实际: %d", n)
+		}
+		result, err := frame.ReadFrame(nil)
+		if err != nil || string(result) != "hi" {
+			t.Errorf("期望读到 hi，实际: %q, %v", result, err)
+		}
+	})
+
+	t.Run("SkipToMagic保留末尾半个Magic", func(t *testing.T) {
+		frame := newFrame()
+		_, _ = frame.ReadFrame([]byte{0x01, 0x02, 0xCA})
+		if n := frame.SkipToMagic(); n != 2 {
+			t.Errorf("期望丢弃 2 字节，实际: %d", n)
+		}
+		result, err := frame.ReadFrame([]byte{0xFE, 0x00, 0x00})
+		if err != nil || result == nil || len(result) != 0 {
+			t.Errorf("期望读到空包，实际: %v, %v", result, err)
+		}
+	})
+
+	t.Run("Encode写入Magic", func(t *testing.T) {
+		frame := newFrame()
+		encoded, err := frame.Hc.Encode([]byte("hi"))
+		if err != nil {
+			t.Fatalf("编码时出现错误: %v", err)
+		}
+		if !bytesEqual(encoded, []byte{0xCA, 0xFE, 0x00, 0x02, 'h', 'i'}) {
+			t.Errorf("编码结果不匹配，实际: %v", encoded)
+		}
+	})
 }
 
-// 基准测试
-func BenchmarkHeaderConfig_Parse2Bytes(b *testing.B) {
+// TestFrame_Compact 测试缓冲区容量收缩
+func TestFrame_Compact(t *testing.T) {
+	newPacket := func(bodyLen int) []byte {
+		packet := make([]byte, 2+bodyLen)
+		binary.BigEndian.PutUint16(packet, uint16(bodyLen))
+		return packet
+	}
+
+	t.Run("手动Compact", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+		// 一个大包后面跟着一个字节的半包
+		packet := append(newPacket(10000), 0x00)
+		frame.buf = make([]byte, 0, 20000)
+		body, err := frame.ReadFrame(packet)
+		if err != nil || len(body) != 10000 {
+			t.Fatalf("读取大包失败: %d, %v", len(body), err)
+		}
+		if cap(frame.buf) < 5000 {
+			t.Fatalf("前置条件不满足，剩余容量: %d", cap(frame.buf))
+		}
+
+		if !frame.Compact() {
+			t.Fatalf("期望发生收缩")
+		}
+		if len(frame.buf) != 1 || cap(frame.buf) != 1 || frame.buf[0] != 0x00 {
+			t.Errorf("收缩后缓冲区不正确: len=%d cap=%d", len(frame.buf), cap(frame.buf))
+		}
+		if frame.Compact() {
+			t.Errorf("已经收缩过，不应再次收缩")
+		}
+
+		// 收缩后剩余数据仍能正确拼出完整帧
+		result, err := frame.ReadFrame([]byte{0x01, 'x'})
+		if err != nil || string(result) != "x" {
+			t.Errorf("期望读到 x，实际: %q, %v", result, err)
+		}
+	})
+
+	t.Run("默认不自动收缩", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+		frame.buf = make([]byte, 0, 20000)
+		_, _ = frame.ReadFrame(newPacket(100))
+		if cap(frame.buf) < 10000 {
+			t.Errorf("未开启 ShrinkThreshold 时不应收缩，容量: %d", cap(frame.buf))
+		}
+	})
+
+	t.Run("超过阈值自动收缩", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, ShrinkThreshold: 4096}}
+		frame.buf = make([]byte, 0, 20000)
+		body, err := frame.ReadFrame(newPacket(100))
+		if err != nil || len(body) != 100 {
+			t.Fatalf("读取失败: %d, %v", len(body), err)
+		}
+		if cap(frame.buf) > 4096 {
+			t.Errorf("超过阈值后应收缩，容量: %d", cap(frame.buf))
+		}
+	})
+
+	t.Run("未超过阈值不收缩", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, ShrinkThreshold: 1 << 20}}
+		frame.buf = make([]byte, 0, 20000)
+		_, _ = frame.ReadFrame(newPacket(100))
+		if cap(frame.buf) < 10000 {
+			t.Errorf("未超过阈值不应收缩，容量: %d", cap(frame.buf))
+		}
+	})
+}
+
+// TestFrame_ReadFrame_CopyFrames 测试返回的帧与内部缓冲区是否共享内存
+func TestFrame_ReadFrame_CopyFrames(t *testing.T) {
+	t.Run("默认返回拷贝", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+		first, err := frame.ReadFrame([]byte{0x00, 0x03, 'a', 'b', 'c'})
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+
+		// 读取之后继续追加新数据，之前返回的帧不受影响
+		second, err := frame.ReadFrame([]byte{0x00, 0x03, 'x', 'y', 'z'})
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		if string(first) != "abc" || string(second) != "xyz" {
+			t.Errorf("之前返回的帧被修改，first: %q, second: %q", first, second)
+		}
+
+		// 调用方 append 返回的帧也不会破坏缓冲区中的数据
+		_, _ = frame.ReadFrame([]byte{0x00, 0x02})
+		_ = append(second, 'X', 'X')
+		third, err := frame.ReadFrame([]byte{'o', 'k'})
+		if err != nil || string(third) != "ok" {
+			t.Errorf("缓冲区被破坏，实际: %q, %v", third, err)
+		}
+	})
+
+	t.Run("ZeroCopy返回内部切片", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, ZeroCopy: true}}
+		first, err := frame.ReadFrame([]byte{0x00, 0x03, 'a', 'b', 'c'})
+		if err != nil || string(first) != "abc" {
+			t.Fatalf("期望读到 abc，实际: %q, %v", first, err)
+		}
+
+		// ZeroCopy 模式下返回的切片只在下一次 ReadFrame 之前有效
+		_, _ = frame.ReadFrame([]byte{0x00, 0x03, 'x', 'y', 'z'})
+		if string(first) == "abc" {
+			t.Errorf("期望 ZeroCopy 模式复用缓冲区，first 仍为 %q", first)
+		}
+	})
+}
+
+// TestFrame_ReadFrame_MaxFrameLength 测试 body 长度上限
+func TestFrame_ReadFrame_MaxFrameLength(t *testing.T) {
 	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 4,
+		MaxFrameLength:    1024,
+	}
+
+	frame := &Frame{Hc: config}
+	// 只收到头部就应该拒绝，而不是等待 1GB 的数据
+	_, err := frame.ReadFrame([]byte{0x40, 0x00, 0x00, 0x00})
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+
+	frame = &Frame{Hc: config}
+	packet := append([]byte{0x00, 0x00, 0x04, 0x00}, make([]byte, 1024)...)
+	result, err := frame.ReadFrame(packet)
+	if err != nil || len(result) != 1024 {
+		t.Errorf("恰好等于上限的帧应正常读取: %d, %v", len(result), err)
+	}
+}
+
+// TestFrame_ReadFrame_Prealloc 测试解析出长度后一次性扩容
+func TestFrame_ReadFrame_Prealloc(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+	_, _ = frame.ReadFrame([]byte{0x04, 0x00, 0x01})
+	if cap(frame.buf) < 2+1024 {
+		t.Errorf("期望一次扩容到整个帧的长度，实际容量: %d", cap(frame.buf))
+	}
+
+	// 没有 MaxFrameLength 时不信任长度字段，预分配有上限
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 4}}
+	_, _ = frame.ReadFrame([]byte{0x7F, 0xFF, 0xFF, 0xFF})
+	if cap(frame.buf) > maxPrealloc {
+		t.Errorf("预分配超过上限，实际容量: %d", cap(frame.buf))
+	}
+}
+
+// TestFrame_ReadFrame_LengthOverflow 测试超大长度字段返回错误而不是 panic
+func TestFrame_ReadFrame_LengthOverflow(t *testing.T) {
+	configs := []*HeaderConfig{
+		{ByteOrder: binary.BigEndian, LengthFieldLength: 4},
+		{ByteOrder: binary.BigEndian, LengthFieldLength: 4, LengthAdjustment: math.MaxInt - 0xFFFFFFFF},
+		{ByteOrder: binary.BigEndian, LengthFieldLength: 4, LengthAdjustment: math.MaxInt - 0x7FFFFFFF},
+	}
+	for i, config := range configs {
+		frame := &Frame{Hc: config}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("配置 %d 发生 panic: %v", i, r)
+				}
+			}()
+			body, err := frame.ReadFrame([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x01})
+			if body != nil {
+				t.Errorf("配置 %d 不应返回数据", i)
+			}
+			// 64 位平台上 0xFFFFFFFF 本身是合法长度，只是数据不够；加上修正量后溢出必须报错
+			if i > 0 && !errors.Is(err, ErrLengthOverflow) {
+				t.Errorf("配置 %d 期望 ErrLengthOverflow，实际: %v", i, err)
+			}
+		}()
+	}
+}
+
+// TestFrame_PeekLength 测试预览下一个帧的长度
+func TestFrame_PeekLength(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{
 		ByteOrder:         binary.BigEndian,
 		LengthFieldLength: 2,
+		LengthAdjustment:  1,
+	}}
+
+	if _, ready, err := frame.PeekLength(); ready || err != nil {
+		t.Errorf("空缓冲区应返回 ready=false，实际: %v, %v", ready, err)
 	}
-	header := []byte{0x01, 0x00}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, _ = config.Parse(header)
+	_, _ = frame.ReadFrame([]byte{0x00})
+	if _, ready, err := frame.PeekLength(); ready || err != nil {
+		t.Errorf("头部不完整应返回 ready=false，实际: %v, %v", ready, err)
+	}
+
+	_, _ = frame.ReadFrame([]byte{0x02, 'a'})
+	length, ready, err := frame.PeekLength()
+	if !ready || err != nil || length != 3 {
+		t.Errorf("期望长度 3，实际: %d, %v, %v", length, ready, err)
+	}
+	if len(frame.buf) != 3 {
+		t.Errorf("PeekLength 不应修改缓冲区，剩余长度: %d", len(frame.buf))
+	}
+
+	// 多次 Peek 结果一致，之后仍能正常读取
+	if again, _, _ := frame.PeekLength(); again != length {
+		t.Errorf("重复 Peek 结果不一致: %d", again)
+	}
+	result, err := frame.ReadFrame([]byte{'b', 'c'})
+	if err != nil || string(result) != "abc" {
+		t.Errorf("期望读到 abc，实际: %q, %v", result, err)
+	}
+
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 4}}
+	_, _ = frame.ReadFrame([]byte{0x00})
+	frame.buf = append(frame.buf, 0x05)
+	if _, _, err := frame.PeekLength(); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
 	}
 }
 
-func BenchmarkHeaderConfig_Parse4Bytes(b *testing.B) {
+// TestFrame_ReadFrame_AutoByteOrder 测试字节序自动检测
+func TestFrame_ReadFrame_AutoByteOrder(t *testing.T) {
+	newConfig := func() *HeaderConfig {
+		return &HeaderConfig{
+			ByteOrder:         binary.BigEndian,
+			LengthFieldLength: 2,
+			MaxFrameLength:    1024,
+			AutoByteOrder:     true,
+		}
+	}
+
+	t.Run("字节序正确", func(t *testing.T) {
+		frame := &Frame{Hc: newConfig()}
+		result, err := frame.ReadFrame([]byte{0x00, 0x02, 'h', 'i'})
+		if err != nil || string(result) != "hi" {
+			t.Fatalf("期望读到 hi，实际: %q, %v", result, err)
+		}
+		if frame.DetectedByteOrder() != binary.BigEndian {
+			t.Errorf("应锁定为大端序，实际: %v", frame.DetectedByteOrder())
+		}
+	})
+
+	t.Run("字节序相反", func(t *testing.T) {
+		frame := &Frame{Hc: newConfig()}
+		if frame.DetectedByteOrder() != nil {
+			t.Errorf("检测前应返回 nil")
+		}
+		// 大端解析为 0x0200 = 512 仍在上限之内，需要选一个超限的值
+		result, err := frame.ReadFrame([]byte{0x05, 0x00, 'h', 'e', 'l', 'l', 'o'})
+		if err != nil || string(result) != "hello" {
+			t.Fatalf("期望读到 hello，实际: %q, %v", result, err)
+		}
+		if frame.DetectedByteOrder() != binary.LittleEndian {
+			t.Errorf("应锁定为小端序，实际: %v", frame.DetectedByteOrder())
+		}
+
+		// 锁定后后续帧都按小端序解析
+		result, err = frame.ReadFrame([]byte{0x01, 0x00, 'x'})
+		if err != nil || string(result) != "x" {
+			t.Errorf("期望读到 x，实际: %q, %v", result, err)
+		}
+	})
+
+	t.Run("两种字节序都不合理", func(t *testing.T) {
+		frame := &Frame{Hc: newConfig()}
+		if _, err := frame.ReadFrame([]byte{0xFF, 0xFF}); !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+		}
+		if frame.DetectedByteOrder() != nil {
+			t.Errorf("检测失败时不应锁定字节序")
+		}
+	})
+
+	t.Run("未开启时不检测", func(t *testing.T) {
+		config := newConfig()
+		config.AutoByteOrder = false
+		frame := &Frame{Hc: config}
+		if _, err := frame.ReadFrame([]byte{0x05, 0x00}); !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+		}
+	})
+}
+
+// TestFrame_PeekLength_AutoByteOrder 测试 PeekLength 和 PendingFrameLength 与 ReadFrame 一样尝试相反的字节序，但不锁定字节序
+func TestFrame_PeekLength_AutoByteOrder(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 100, AutoByteOrder: true}}
+
+	// 大端解析为 0x0500 = 1280 超过上限，小端为 5；通过 Restore 放入的数据还没有被 ReadFrame 解析过
+	frame.Restore([]byte{0x05, 0x00, 'a'})
+	if length, ready, err := frame.PeekLength(); err != nil || !ready || length != 5 {
+		t.Errorf("期望长度 5，实际: %d, %v, %v", length, ready, err)
+	}
+	if length, ok := frame.PendingFrameLength(); !ok || length != 7 {
+		t.Errorf("期望线路长度 7，实际: %d, %v", length, ok)
+	}
+	if order := frame.DetectedByteOrder(); order != nil {
+		t.Errorf("只读的方法不应锁定字节序，实际: %v", order)
+	}
+
+	body, err := frame.ReadFrame([]byte{'b', 'c', 'd', 'e'})
+	if err != nil || string(body) != "abcde" {
+		t.Fatalf("期望读到 abcde，实际: %q, %v", body, err)
+	}
+	if frame.DetectedByteOrder() != binary.LittleEndian {
+		t.Errorf("应锁定为小端序，实际: %v", frame.DetectedByteOrder())
+	}
+}
+
+// TestFrame_ReadFrame_Callbacks 测试 OnFrame / OnError 回调
+func TestFrame_ReadFrame_Callbacks(t *testing.T) {
+	var sizes []int
+	var errs []error
+
 	config := &HeaderConfig{
 		ByteOrder:         binary.BigEndian,
-		LengthFieldLength: 4,
+		LengthFieldLength: 2,
+		MaxFrameLength:    8,
 	}
-	header := []byte{0x00, 0x00, 0x01, 0x00}
+	frame := &Frame{Hc: config}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, _ = config.Parse(header)
+	config.OnFrame = func(size int) {
+		sizes = append(sizes, size)
+		// 回调在锁外执行，可以安全地调用 Frame 的方法
+		_, _, _ = frame.PeekLength()
+	}
+	config.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+
+	_, _ = frame.ReadFrame([]byte{0x00, 0x03, 'a', 'b', 'c', 0x00})
+	_, _ = frame.ReadFrame([]byte{0x00})
+	_, _ = frame.ReadFrame(nil)
+	frame.buf = []byte{0x00, 0x10}
+	_, _ = frame.ReadFrame(nil)
+
+	if len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 0 {
+		t.Errorf("OnFrame 调用不正确: %v", sizes)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrFrameTooLarge) {
+		t.Errorf("OnError 调用不正确: %v", errs)
 	}
 }
 
-func BenchmarkFrame_ReadFrame(b *testing.B) {
-	config := &HeaderConfig{
+// TestFrame_ReadFrame_Resync 测试 Magic 不匹配时自动重新同步
+func TestFrame_ReadFrame_Resync(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{
 		ByteOrder:         binary.BigEndian,
 		LengthFieldLength: 2,
+		Magic:             []byte{0xCA, 0xFE},
+		Resync:            true,
+	}}
+
+	// 开头有 3 个垃圾字节，其中一个是 Magic 的第一个字节
+	result, err := frame.ReadFrame([]byte{0x01, 0xCA, 0x02, 0xCA, 0xFE, 0x00, 0x02, 'h', 'i'})
+	if err != nil || string(result) != "hi" {
+		t.Fatalf("期望读到 hi，实际: %q, %v", result, err)
+	}
+	if n := frame.ResyncedBytes(); n != 3 {
+		t.Errorf("期望丢弃 3 字节，实际: %d", n)
 	}
 
-	frame := &Frame{
-		Hc:  config,
-		buf: make([]byte, 0),
+	// 垃圾数据分多次到达
+	for _, raw := range [][]byte{{0xFF, 0xFF}, {0xCA}} {
+		if result, err := frame.ReadFrame(raw); err != nil || result != nil {
+			t.Fatalf("期望 (nil, nil)，实际: %v, %v", result, err)
+		}
+	}
+	result, err = frame.ReadFrame([]byte{0xFE, 0x00, 0x01, 'x'})
+	if err != nil || string(result) != "x" {
+		t.Errorf("期望读到 x，实际: %q, %v", result, err)
+	}
+	if n := frame.ResyncedBytes(); n != 5 {
+		t.Errorf("期望累计丢弃 5 字节，实际: %d", n)
 	}
 
-	packet := []byte{0x00, 0x05, 'h', 'e', 'l', 'l', 'o'}
+	// 没有配置 Magic 时 Resync 不起作用，其他错误照常返回
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 3, Resync: true}}
+	if _, err := frame.ReadFrame([]byte{0x00, 0x00, 0x01}); err == nil {
+		t.Errorf("期望出现错误，但没有错误")
+	}
+}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, _ = frame.ReadFrame(packet)
+// TestFrame_ReadFrame_ASCIIDecimal 测试 ASCII 十进制长度
+func TestFrame_ReadFrame_ASCIIDecimal(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *HeaderConfig
+		inputData     [][]byte
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:      "默认空格结束",
+			config:    &HeaderConfig{LengthEncoding: LengthASCIIDecimal},
+			inputData: [][]byte{[]byte("5 hello")},
+			expected:  "hello",
+		},
+		{
+			name:      "数字分多次到达",
+			config:    &HeaderConfig{LengthEncoding: LengthASCIIDecimal},
+			inputData: [][]byte{[]byte("1"), []byte("1"), []byte(" hello"), []byte(" world")},
+			expected:  "hello world",
+		},
+		{
+			name:      "自定义结束符",
+			config:    &HeaderConfig{LengthEncoding: LengthASCIIDecimal, LengthTerminator: ':'},
+			inputData: [][]byte{[]byte("2:hi")},
+			expected:  "hi",
+		},
+		{
+			name:      "长度为0",
+			config:    &HeaderConfig{LengthEncoding: LengthASCIIDecimal},
+			inputData: [][]byte{[]byte("0 ")},
+			expected:  "",
+		},
+		{
+			name:          "非数字字符",
+			config:        &HeaderConfig{LengthEncoding: LengthASCIIDecimal},
+			inputData:     [][]byte{[]byte("1x hello")},
+			expectedError: true,
+		},
+		{
+			name:          "没有数字",
+			config:        &HeaderConfig{LengthEncoding: LengthASCIIDecimal},
+			inputData:     [][]byte{[]byte(" hello")},
+			expectedError: true,
+		},
+		{
+			name:          "数字过长",
+			config:        &HeaderConfig{LengthEncoding: LengthASCIIDecimal},
+			inputData:     [][]byte{[]byte("99999999999999999999")},
+			expectedError: true,
+		},
+		{
+			name:          "超过最大长度",
+			config:        &HeaderConfig{LengthEncoding: LengthASCIIDecimal, MaxFrameLength: 10},
+			inputData:     [][]byte{[]byte("11 ")},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := &Frame{Hc: tt.config}
+			var result []byte
+			var err error
+			for _, input := range tt.inputData {
+				result, err = frame.ReadFrame(input)
+				if err != nil || result != nil {
+					break
+				}
+			}
+
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("期望出现错误，但没有错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+			}
+			if result == nil || string(result) != tt.expected {
+				t.Errorf("包内容不匹配，期望: %q, 实际: %q", tt.expected, result)
+			}
+
+			encoded, err := tt.config.Encode([]byte(tt.expected))
+			if err != nil {
+				t.Fatalf("编码时出现错误: %v", err)
+			}
+			if string(encoded) != string(bytes.Join(tt.inputData, nil)) {
+				t.Errorf("编码结果不匹配，实际: %q", encoded)
+			}
+		})
+	}
+}
+
+// TestFrame_DrainAll 测试取出缓冲区中剩余的所有帧
+func TestFrame_DrainAll(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 16}
+
+	tests := []struct {
+		name            string
+		buffered        []byte
+		expectedFrames  []string
+		expectedPartial []byte
+	}{
+		{
+			name:     "空缓冲区",
+			buffered: nil,
+		},
+		{
+			name:           "全部是完整帧",
+			buffered:       []byte{0x00, 0x01, 'a', 0x00, 0x02, 'b', 'c'},
+			expectedFrames: []string{"a", "bc"},
+		},
+		{
+			name:            "最后一个帧不完整",
+			buffered:        []byte{0x00, 0x01, 'a', 0x00, 0x05, 'b', 'c'},
+			expectedFrames:  []string{"a"},
+			expectedPartial: []byte{0x00, 0x05, 'b', 'c'},
+		},
+		{
+			name:            "只剩半个头部",
+			buffered:        []byte{0x00, 0x01, 'a', 0x00},
+			expectedFrames:  []string{"a"},
+			expectedPartial: []byte{0x00},
+		},
+		{
+			name:            "遇到错误停止",
+			buffered:        []byte{0x00, 0x01, 'a', 0xFF, 0xFF, 0x00, 0x01, 'b'},
+			expectedFrames:  []string{"a"},
+			expectedPartial: []byte{0xFF, 0xFF, 0x00, 0x01, 'b'},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := &Frame{Hc: config}
+			frame.buf = append(frame.buf, tt.buffered...)
+
+			frames, partial := frame.DrainAll()
+			if len(frames) != len(tt.expectedFrames) {
+				t.Fatalf("帧数量不匹配，期望: %d, 实际: %d", len(tt.expectedFrames), len(frames))
+			}
+			for i := range frames {
+				if string(frames[i]) != tt.expectedFrames[i] {
+					t.Errorf("第 %d 个帧不匹配，期望: %q, 实际: %q", i+1, tt.expectedFrames[i], frames[i])
+				}
+			}
+			if !bytesEqual(partial, tt.expectedPartial) {
+				t.Errorf("剩余数据不匹配，期望: %v, 实际: %v", tt.expectedPartial, partial)
+			}
+
+			// 返回的是拷贝，修改它不影响缓冲区
+			if len(partial) > 0 {
+				partial[0] ^= 0xFF
+				if frame.buf[0] == partial[0] {
+					t.Errorf("partial 应该是缓冲区的拷贝")
+				}
+			}
+		})
+	}
+}
+
+// TestFrame_ReadFrame_BufferPool 测试通过 sync.Pool 复用帧内存
+func TestFrame_ReadFrame_BufferPool(t *testing.T) {
+	allocated := 0
+	pool := &sync.Pool{New: func() any {
+		allocated++
+		b := make([]byte, 0, 64)
+		return &b
+	}}
+	frame := &Frame{Hc: &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		BufferPool:        pool,
+	}}
+
+	first, err := frame.ReadFrame([]byte{0x00, 0x03, 'a', 'b', 'c'})
+	if err != nil || string(first) != "abc" {
+		t.Fatalf("期望读到 abc，实际: %q, %v", first, err)
+	}
+	if cap(first) != 64 {
+		t.Errorf("返回的帧应来自池，容量: %d", cap(first))
+	}
+	frame.Release(first)
+
+	second, err := frame.ReadFrame([]byte{0x00, 0x02, 'x', 'y'})
+	if err != nil || string(second) != "xy" {
+		t.Fatalf("期望读到 xy，实际: %q, %v", second, err)
+	}
+	if cap(second) != 64 {
+		t.Errorf("返回的帧应来自池，容量: %d", cap(second))
+	}
+	frame.Release(second)
+
+	// 池中的对象容量不足时重新分配
+	large := append([]byte{0x00, 0x80}, make([]byte, 128)...)
+	body, err := frame.ReadFrame(large)
+	if err != nil || len(body) != 128 {
+		t.Fatalf("读取大包失败: %d, %v", len(body), err)
+	}
+	if allocated == 0 {
+		t.Errorf("应通过池的 New 获取内存")
+	}
+
+	// ZeroCopy 时返回的是内部缓冲区，Release 不能把它放进池
+	zc := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, ZeroCopy: true, BufferPool: &sync.Pool{}}}
+	internal, _ := zc.ReadFrame([]byte{0x00, 0x01, 'z'})
+	zc.Release(internal)
+	if v := zc.Hc.BufferPool.Get(); v != nil {
+		t.Errorf("ZeroCopy 时 Release 不应归还内部缓冲区")
+	}
+}
+
+// TestFrame_ReadFrame_ConfigChangedMidFrame 测试缓冲区有残留数据时修改配置
+func TestFrame_ReadFrame_ConfigChangedMidFrame(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	frame := &Frame{Hc: config}
+
+	// 只缓冲了头部的一个字节，然后修改长度字段宽度
+	if result, err := frame.ReadFrame([]byte{0x00}); err != nil || result != nil {
+		t.Fatalf("期望 (nil, nil)，实际: %v, %v", result, err)
+	}
+	config.LengthFieldLength = 4
+	if _, err := frame.ReadFrame([]byte{0x00, 0x00, 0x01, 'a'}); !errors.Is(err, ErrConfigChangedMidFrame) {
+		t.Fatalf("期望 ErrConfigChangedMidFrame，实际: %v", err)
+	}
+
+	// 改回原来的配置后可以继续解析
+	config.LengthFieldLength = 2
+	result, err := frame.ReadFrame(nil)
+	if err != nil || !bytesEqual(result, []byte{}) {
+		t.Errorf("期望读到空包，实际: %v, %v", result, err)
+	}
+
+	// 在帧边界修改配置是允许的
+	frame.buf = frame.buf[:0]
+	config.LengthFieldLength = 4
+	result, err = frame.ReadFrame([]byte{0x00, 0x00, 0x00, 0x01, 'b'})
+	if err != nil || string(result) != "b" {
+		t.Errorf("期望读到 b，实际: %q, %v", result, err)
+	}
+
+	// 修改字节序同样会被检测到
+	_, _ = frame.ReadFrame([]byte{0x00})
+	config.ByteOrder = binary.LittleEndian
+	if _, err := frame.ReadFrame([]byte{0x00}); !errors.Is(err, ErrConfigChangedMidFrame) {
+		t.Errorf("期望 ErrConfigChangedMidFrame，实际: %v", err)
+	}
+}
+
+// TestFrame_ReadFrame_LengthFieldOffset 测试长度字段之前有固定命令块的协议
+func TestFrame_ReadFrame_LengthFieldOffset(t *testing.T) {
+	// 3 字节命令块 + 2 字节长度 + body
+	data := []byte{'C', 'M', 'D', 0x00, 0x02, 'h', 'i'}
+
+	// 默认剥离命令块和长度字段
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: 3}}
+	if body, err := frame.ReadFrame(data[:4]); body != nil || err != nil {
+		t.Fatalf("数据不足应返回 (nil, nil)，实际: %q, %v", body, err)
+	}
+	body, err := frame.ReadFrame(data[4:])
+	if err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+
+	// StripNone 时保留命令块
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: 3, InitialBytesToStrip: StripNone}}
+	body, err = frame.ReadFrame(data)
+	if err != nil || !bytesEqual(body, data) {
+		t.Errorf("期望完整帧，实际: %v, %v", body, err)
+	}
+
+	// 与 Magic 组合：Magic + 命令块 + 长度，只剥离 Magic
+	config := &HeaderConfig{
+		ByteOrder:            binary.BigEndian,
+		LengthFieldLength:    2,
+		LengthFieldOffset:    3,
+		Magic:                []byte{0xAB},
+		InitialBytesToStrip:  1,
+		LengthIncludesHeader: true,
+	}
+	frame = &Frame{Hc: config}
+	body, err = frame.ReadFrame([]byte{0xAB, 'C', 'M', 'D', 0x00, 0x08, 'h', 'i'})
+	if expected := []byte{'C', 'M', 'D', 0x00, 0x08, 'h', 'i'}; err != nil || !bytesEqual(body, expected) {
+		t.Errorf("期望命令块 + 长度 + body，实际: %v, %v", body, err)
+	}
+
+	if err := (&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthFieldOffset: -1}).Validate(); err == nil {
+		t.Error("负数 LengthFieldOffset 应校验失败")
+	}
+}
+
+// TestFrame_Stats 测试累计统计
+func TestFrame_Stats(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		Magic:             []byte{0xAB},
+		Resync:            true,
+	}}
+
+	// 2 个垃圾字节 + 一个完整帧 + 半个帧
+	_, _ = frame.ReadFrame([]byte{0x01, 0x02, 0xAB, 0x00, 0x02, 'h', 'i', 0xAB, 0x00})
+	stats := frame.Stats()
+	expected := FrameStats{FramesDecoded: 1, BytesConsumed: 2 + 5, BytesBuffered: 2}
+	if stats != expected {
+		t.Errorf("期望 %+v，实际: %+v", expected, stats)
+	}
+}
+
+// TestFrame_ReadFrame_LengthFunc 测试自定义长度计算
+func TestFrame_ReadFrame_LengthFunc(t *testing.T) {
+	// 头部：1 字节低位长度 + 1 字节类型 + 1 字节高位长度
+	config := &HeaderConfig{
+		LengthFieldLength: 3,
+		LengthFunc: func(header []byte) (int, error) {
+			if header[1] == 0xFF {
+				return 0, errors.New("bad type")
+			}
+			return int(header[2])<<8 | int(header[0]), nil
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("配置应合法，实际: %v", err)
+	}
+
+	frame := &Frame{Hc: config}
+	if body, err := frame.ReadFrame([]byte{0x02, 0x01}); body != nil || err != nil {
+		t.Fatalf("头部不完整应返回 (nil, nil)，实际: %q, %v", body, err)
+	}
+	body, err := frame.ReadFrame([]byte{0x00, 'h', 'i'})
+	if err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+
+	frame = &Frame{Hc: config}
+	body, err = frame.ReadFrame(append([]byte{0x00, 0x01, 0x01}, make([]byte, 256)...))
+	if err != nil || len(body) != 256 {
+		t.Errorf("期望 256 字节，实际: %d, %v", len(body), err)
+	}
+
+	// LengthFunc 的错误原样返回
+	frame = &Frame{Hc: config}
+	if _, err := frame.ReadFrame([]byte{0x00, 0xFF, 0x00}); err == nil || err.Error() != "bad type" {
+		t.Errorf("期望 bad type 错误，实际: %v", err)
+	}
+}
+
+// TestFrame_ReadFrame_MinFrameLength 测试拒绝过小的帧
+func TestFrame_ReadFrame_MinFrameLength(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MinFrameLength: 2}
+
+	frame := &Frame{Hc: config}
+	if _, err := frame.ReadFrame([]byte{0x00, 0x00}); err != ErrFrameTooSmall {
+		t.Errorf("空帧期望 ErrFrameTooSmall，实际: %v", err)
+	}
+
+	frame = &Frame{Hc: config}
+	if body, err := frame.ReadFrame([]byte{0x00, 0x02, 'h', 'i'}); err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+
+	if _, err := config.Encode([]byte("h")); err != ErrFrameTooSmall {
+		t.Errorf("编码过小的帧期望 ErrFrameTooSmall，实际: %v", err)
+	}
+
+	invalid := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MinFrameLength: 10, MaxFrameLength: 5}
+	if err := invalid.Validate(); err == nil {
+		t.Error("MinFrameLength 大于 MaxFrameLength 应校验失败")
+	}
+}
+
+// TestFrame_ReadFrame_Trailer 测试长度字段不包含的固定尾部
+func TestFrame_ReadFrame_Trailer(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, TrailerLength: 4}
+	frame := &Frame{Hc: config}
+
+	if frame.Trailer() != nil {
+		t.Error("还没有取出帧时 Trailer 应返回 nil")
+	}
+
+	// 尾部未收齐时不返回帧
+	if body, err := frame.ReadFrame([]byte{0x00, 0x02, 'h', 'i', 'T', 'R'}); body != nil || err != nil {
+		t.Fatalf("尾部不完整应返回 (nil, nil)，实际: %q, %v", body, err)
+	}
+	body, err := frame.ReadFrame([]byte{'L', 'R', 0x00, 0x01, 'x', '1', '2', '3', '4'})
+	if err != nil || string(body) != "hi" {
+		t.Fatalf("期望 hi，实际: %q, %v", body, err)
+	}
+	if trailer := frame.Trailer(); string(trailer) != "TRLR" {
+		t.Errorf("期望尾部 TRLR，实际: %q", trailer)
+	}
+
+	// 缓冲区越过尾部，下一个帧可以正常解析
+	body, err = frame.ReadFrame(nil)
+	if err != nil || string(body) != "x" {
+		t.Fatalf("期望 x，实际: %q, %v", body, err)
+	}
+	if trailer := frame.Trailer(); string(trailer) != "1234" {
+		t.Errorf("期望尾部 1234，实际: %q", trailer)
+	}
+
+	// 编码时 body 的最后 TrailerLength 个字节作为尾部
+	encoded, err := config.Encode([]byte("hiTRLR"))
+	if expected := []byte{0x00, 0x02, 'h', 'i', 'T', 'R', 'L', 'R'}; err != nil || !bytesEqual(encoded, expected) {
+		t.Errorf("编码结果不匹配，期望: %v, 实际: %v, %v", expected, encoded, err)
+	}
+	if _, err := config.Encode([]byte("hi")); err == nil {
+		t.Error("body 短于尾部时应返回错误")
+	}
+}
+
+// TestFrame_DebugDump 测试缓冲区调试输出
+func TestFrame_DebugDump(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, DebugDumpLimit: 4}}
+	_, _ = frame.ReadFrame([]byte{0x00, 0x10, 'h', 'e', 'l', 'l', 'o'})
+
+	dump := frame.DebugDump()
+	expected := "7 bytes buffered\n" +
+		"00000000  00 10 68 65                                       |..he|\n" +
+		"... 3 more bytes\n"
+	if dump != expected {
+		t.Errorf("输出不匹配，期望:\n%s实际:\n%s", expected, dump)
+	}
+
+	// 不影响解码
+	if frame.buffered() != 7 {
+		t.Errorf("DebugDump 不应消费数据，剩余: %d", frame.buffered())
+	}
+}
+
+// TestFrame_ReadFrameInto 测试读取到调用方提供的缓冲区
+func TestFrame_ReadFrameInto(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+	dst := make([]byte, 8)
+
+	if n, ok, err := frame.ReadFrameInto([]byte{0x00, 0x05, 'h'}, dst); n != 0 || ok || err != nil {
+		t.Fatalf("数据不足应返回 ok=false，实际: %d, %v, %v", n, ok, err)
+	}
+	n, ok, err := frame.ReadFrameInto([]byte{'e', 'l', 'l', 'o', 0x00, 0x09}, dst)
+	if !ok || err != nil || string(dst[:n]) != "hello" {
+		t.Fatalf("期望 hello，实际: %q, %v, %v", dst[:n], ok, err)
+	}
+
+	// dst 放不下时不消费，按需要的长度换一个 dst 可以取出
+	_, ok, err = frame.ReadFrameInto([]byte("123456789"), dst)
+	var short *ShortBufferError
+	if ok || !errors.Is(err, io.ErrShortBuffer) || !errors.As(err, &short) || short.Need != 9 || short.Have != 8 {
+		t.Fatalf("dst 太小应返回 ShortBufferError，实际: %v, %v", ok, err)
+	}
+	if _, ok, err := frame.ReadFrameInto(nil, dst); ok || err == nil {
+		t.Fatalf("重试时 dst 仍然太小应返回错误，实际: %v, %v", ok, err)
+	}
+	big := make([]byte, short.Need)
+	n, ok, err = frame.ReadFrameInto(nil, big)
+	if !ok || err != nil || string(big[:n]) != "123456789" {
+		t.Errorf("期望 123456789，实际: %q, %v, %v", big[:n], ok, err)
+	}
+
+	// 不做任何分配
+	data := []byte{0x00, 0x02, 'h', 'i'}
+	allocs := testing.AllocsPerRun(100, func() { _, _, _ = frame.ReadFrameInto(data, dst) })
+	if allocs != 0 {
+		t.Errorf("期望零分配，实际: %v", allocs)
+	}
+}
+
+// TestFrame_ReadFrame_DropOversized 测试丢弃超长帧后继续解析
+func TestFrame_ReadFrame_DropOversized(t *testing.T) {
+	var dropped []int
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		MaxFrameLength:    4,
+		DropOversized:     true,
+		OnDropped:         func(size int) { dropped = append(dropped, size) },
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("配置应合法，实际: %v", err)
+	}
+	frame := &Frame{Hc: config}
+
+	// 超长帧分多次到达，期间不返回任何数据
+	for _, chunk := range [][]byte{{0x00, 0x08, '1', '2'}, {'3', '4', '5'}, {'6', '7'}} {
+		if body, err := frame.ReadFrame(chunk); body != nil || err != nil {
+			t.Fatalf("丢弃超长帧时应返回 (nil, nil)，实际: %q, %v", body, err)
+		}
+	}
+	if len(dropped) != 1 || dropped[0] != 8 {
+		t.Errorf("期望 OnDropped(8)，实际: %v", dropped)
+	}
+
+	// 超长帧的最后一个字节和下一个正常帧一起到达
+	body, err := frame.ReadFrame([]byte{'8', 0x00, 0x02, 'h', 'i'})
+	if err != nil || string(body) != "hi" {
+		t.Fatalf("期望 hi，实际: %q, %v", body, err)
+	}
+
+	// 连续的超长帧在一次输入中全部丢弃
+	body, err = frame.ReadFrame([]byte{0x00, 0x05, 'a', 'b', 'c', 'd', 'e', 0x00, 0x06, 'a', 'b', 'c', 'd', 'e', 'f', 0x00, 0x01, '!'})
+	if err != nil || string(body) != "!" {
+		t.Fatalf("期望 !，实际: %q, %v", body, err)
+	}
+	if len(dropped) != 3 {
+		t.Errorf("期望丢弃 3 个帧，实际: %v", dropped)
+	}
+	if stats := frame.Stats(); stats.BytesConsumed != 10+4+7+8+3 {
+		t.Errorf("丢弃的字节应计入 BytesConsumed，实际: %d", stats.BytesConsumed)
+	}
+}
+
+// TestFrame_ReadFrame_ReturnFullFrame 测试返回完整的原始帧
+func TestFrame_ReadFrame_ReturnFullFrame(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		ChecksumLength:    1,
+		ChecksumFunc:      func(b []byte) uint32 { return uint32(len(b)) },
+		TrailerLength:     1,
+		ReturnFullFrame:   true,
+	}
+	data := []byte{0x00, 0x03, 'h', 'i', 0x02, '$'}
+
+	frame := &Frame{Hc: config}
+	body, err := frame.ReadFrame(data)
+	if err != nil || !bytesEqual(body, data) {
+		t.Fatalf("期望完整的原始帧，实际: %v, %v", body, err)
+	}
+
+	// 返回的是拷贝
+	data[2] = 'X'
+	if body[2] != 'h' {
+		t.Error("返回的帧不应引用输入数据")
+	}
+
+	config.InitialBytesToStrip = StripNone
+	if err := config.Validate(); err == nil {
+		t.Error("ReturnFullFrame 与 InitialBytesToStrip 同时使用应校验失败")
+	}
+}
+
+// TestFrame_ReadFrame_PlausibilityCheck 测试自定义长度合理性检查
+func TestFrame_ReadFrame_PlausibilityCheck(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		PlausibilityCheck: func(length int) bool { return length%2 == 0 },
+	}
+
+	frame := &Frame{Hc: config}
+	if body, err := frame.ReadFrame([]byte{0x00, 0x02, 'h', 'i'}); err != nil || string(body) != "hi" {
+		t.Errorf("期望 hi，实际: %q, %v", body, err)
+	}
+
+	// 头部一到就报错，不等待 body
+	if _, err := frame.ReadFrame([]byte{0x00, 0x03}); err != ErrImplausibleLength {
+		t.Errorf("期望 ErrImplausibleLength，实际: %v", err)
+	}
+	if frame.buffered() != 2 {
+		t.Errorf("不应消费数据，剩余: %d", frame.buffered())
+	}
+}
+
+// TestFrame_SetMaxFrameLength 测试运行时修改上限只影响之后解析出头部的帧
+func TestFrame_SetMaxFrameLength(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 16}
+	frame := &Frame{Hc: config}
+
+	// 头部已经通过检查的帧在收紧上限后仍然可以收完
+	if body, err := frame.ReadFrame([]byte{0x00, 0x08, 1, 2, 3}); body != nil || err != nil {
+		t.Fatalf("数据不足时应返回 (nil, nil): %v, %v", body, err)
+	}
+	if err := frame.SetMaxFrameLength(4); err != nil {
+		t.Fatal(err)
+	}
+	body, err := frame.ReadFrame([]byte{4, 5, 6, 7, 8})
+	if err != nil || !bytesEqual(body, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatalf("已经开始接收的帧应按原来的上限处理: %v, %v", body, err)
+	}
+
+	// 之后的帧按新的上限检查
+	if _, err := frame.ReadFrame([]byte{0x00, 0x08}); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if frame.MaxFrameLength() != 4 || config.MaxFrameLength != 16 {
+		t.Errorf("只应修改这个 Frame 的上限: %d, %d", frame.MaxFrameLength(), config.MaxFrameLength)
+	}
+
+	if err := frame.SetMaxFrameLength(-1); err == nil {
+		t.Error("负数上限应返回错误")
+	}
+	if err := (&Frame{Hc: &HeaderConfig{MaxFrameLength: 8, DropOversized: true}}).SetMaxFrameLength(0); err == nil {
+		t.Error("DropOversized 时取消上限应返回错误")
+	}
+
+	// 新的上限同样要满足 WarnFrameLength 和 HighWaterMark 的约束
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 10, HighWaterMark: 12, WarnFrameLength: 6}}
+	if err := frame.SetMaxFrameLength(100); err == nil {
+		t.Error("HighWaterMark 容纳不下新的上限时应返回错误")
+	}
+	if err := frame.SetMaxFrameLength(6); err == nil {
+		t.Error("新的上限不大于 WarnFrameLength 时应返回错误")
+	}
+	if frame.MaxFrameLength() != 10 {
+		t.Errorf("返回错误时上限应保持不变，实际: %d", frame.MaxFrameLength())
+	}
+	if err := frame.SetMaxFrameLength(8); err != nil {
+		t.Errorf("期望 nil，实际: %v", err)
+	}
+}
+
+// TestFrame_ReadFrame_OnHeader 测试 OnHeader 在 body 到达之前触发，且每个帧只触发一次
+func TestFrame_ReadFrame_OnHeader(t *testing.T) {
+	var events []string
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		OnHeader:          func(length int) { events = append(events, fmt.Sprintf("header %d", length)) },
+		OnFrame:           func(size int) { events = append(events, fmt.Sprintf("frame %d", size)) },
+	}
+	frame := &Frame{Hc: config}
+
+	// 头部先到，body 分两次到达
+	for _, chunk := range [][]byte{{0x00}, {0x03, 'a'}, {'b'}, {'c', 0x00, 0x01, 'd'}} {
+		if _, err := frame.ReadFrame(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第二个帧随上一次输入一起到达，取出时只触发一次 OnHeader
+	if body, err := frame.ReadFrame(nil); err != nil || string(body) != "d" {
+		t.Fatalf("期望读取第二个帧: %q, %v", body, err)
+	}
+
+	want := []string{"header 3", "frame 3", "header 1", "frame 1"}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("期望 %v，实际: %v", want, events)
+	}
+}
+
+// TestFrame_ReadFrame_LengthUnitBytes 测试以 16 位字为单位的长度字段
+func TestFrame_ReadFrame_LengthUnitBytes(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthUnitBytes: 2}
+	frame := &Frame{Hc: config}
+
+	// 长度字段为 3 个字，body 为 6 字节
+	packet := []byte{0x00, 0x03, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x00}
+	body, err := frame.ReadFrame(packet)
+	if err != nil || !bytesEqual(body, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}) {
+		t.Fatalf("期望 6 字节的 body，实际: %v, %v", body, err)
+	}
+	if buffered := frame.buffered(); buffered != 1 {
+		t.Errorf("期望剩余 1 字节，实际: %d", buffered)
+	}
+
+	encoded, err := config.Encode(body)
+	if err != nil || !bytesEqual(encoded, packet[:8]) {
+		t.Errorf("编码结果不正确: %v, %v", encoded, err)
+	}
+	if _, err := config.Encode([]byte{0x01, 0x02, 0x03}); err == nil {
+		t.Error("body 长度不是字长的整数倍时应返回错误")
+	}
+
+	// 乘以单位之后溢出 int
+	frame = &Frame{Hc: &HeaderConfig{LengthEncoding: LengthVarint, LengthUnitBytes: 4}}
+	if _, err := frame.ReadFrame(binary.AppendUvarint(nil, math.MaxInt/2)); !errors.Is(err, ErrLengthOverflow) {
+		t.Errorf("期望 ErrLengthOverflow，实际: %v", err)
+	}
+	if err := (&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, LengthUnitBytes: -1}).Validate(); err == nil {
+		t.Error("负数 LengthUnitBytes 应校验失败")
+	}
+}
+
+// TestSplitFrames 测试一次性切分内存中的完整数据
+func TestSplitFrames(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8}
+	data := []byte{0x00, 0x02, 'h', 'i', 0x00, 0x00, 0x00, 0x03, 'f', 'o', 'o', 0x00, 0x05, 'a', 'b'}
+	original := append([]byte(nil), data...)
+
+	frames, partial, err := SplitFrames(data, config)
+	if err != nil {
+		t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+	}
+	if len(frames) != 3 || string(frames[0]) != "hi" || frames[1] == nil || len(frames[1]) != 0 || string(frames[2]) != "foo" {
+		t.Errorf("切分结果不正确: %q", frames)
+	}
+	if !bytesEqual(partial, []byte{0x00, 0x05, 'a', 'b'}) {
+		t.Errorf("期望末尾不完整的数据，实际: %v", partial)
+	}
+	if !bytesEqual(data, original) {
+		t.Error("不应修改 data")
+	}
+
+	// 长度检查与 ReadFrame 相同，出错时返回之前的帧和出错位置开始的数据
+	frames, partial, err = SplitFrames([]byte{0x00, 0x01, 'a', 0x00, 0x10, 'b'}, config)
+	if !errors.Is(err, ErrFrameTooLarge) || len(frames) != 1 || !bytesEqual(partial, []byte{0x00, 0x10, 'b'}) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %q, %v, %v", frames, partial, err)
+	}
+}
+
+// TestFrame_ReadFrame_EmptyBody 测试空帧返回非 nil 的空切片，数据不足返回 nil，调用方依赖 body != nil 区分两者
+func TestFrame_ReadFrame_EmptyBody(t *testing.T) {
+	configs := map[string]*HeaderConfig{
+		"默认":         {ByteOrder: binary.BigEndian, LengthFieldLength: 2},
+		"ZeroCopy":   {ByteOrder: binary.BigEndian, LengthFieldLength: 2, ZeroCopy: true},
+		"BufferPool": {ByteOrder: binary.BigEndian, LengthFieldLength: 2, BufferPool: &sync.Pool{}},
+	}
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			frame := &Frame{Hc: config}
+			body, err := frame.ReadFrame([]byte{0x00, 0x00, 0x00})
+			if err != nil || body == nil || len(body) != 0 {
+				t.Fatalf("空帧应返回非 nil 的空切片，实际: %v (nil=%v), %v", body, body == nil, err)
+			}
+			body, err = frame.ReadFrame(nil)
+			if err != nil || body != nil {
+				t.Errorf("数据不足应返回 nil，实际: %v, %v", body, err)
+			}
+		})
+	}
+}
+
+// TestFrame_PendingFrameLength 测试查询正在等待的帧的长度
+func TestFrame_PendingFrameLength(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, TrailerLength: 1}}
+	if _, haveHeader := frame.PendingFrameLength(); haveHeader {
+		t.Error("缓冲区为空时不应有头部")
+	}
+
+	_, _ = frame.ReadFrame([]byte{0x00})
+	if _, haveHeader := frame.PendingFrameLength(); haveHeader {
+		t.Error("头部不完整时不应有头部")
+	}
+
+	_, _ = frame.ReadFrame([]byte{0x05, 'a', 'b'})
+	length, haveHeader := frame.PendingFrameLength()
+	if !haveHeader || length != 2+5+1 {
+		t.Fatalf("期望总长度 8，实际: %d, %v", length, haveHeader)
+	}
+	if missing := length - frame.Stats().BytesBuffered; missing != 4 {
+		t.Errorf("期望还缺 4 字节，实际: %d", missing)
+	}
+}
+
+// TestFrame_ReadFrame_VarintFlavor 测试无符号和 zigzag 两种 varint 长度与标准库编码互通
+func TestFrame_ReadFrame_VarintFlavor(t *testing.T) {
+	body := bytes.Repeat([]byte{'x'}, 300)
+	tests := []struct {
+		name   string
+		flavor VarintFlavor
+		header []byte
+	}{
+		{"无符号", VarintUnsigned, binary.AppendUvarint(nil, uint64(len(body)))},
+		{"zigzag", VarintZigzag, binary.AppendVarint(nil, int64(len(body)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &HeaderConfig{LengthEncoding: LengthVarint, VarintFlavor: tt.flavor}
+			packet := append(append([]byte(nil), tt.header...), body...)
+
+			frame := &Frame{Hc: config}
+			result, err := frame.ReadFrame(packet)
+			if err != nil || !bytes.Equal(result, body) {
+				t.Fatalf("解码失败: %d, %v", len(result), err)
+			}
+			encoded, err := config.Encode(body)
+			if err != nil || !bytes.Equal(encoded, packet) {
+				t.Errorf("编码结果与标准库不一致: %v", encoded[:len(tt.header)])
+			}
+		})
+	}
+
+	frame := &Frame{Hc: &HeaderConfig{LengthEncoding: LengthVarint, VarintFlavor: VarintZigzag}}
+	if _, err := frame.ReadFrame(binary.AppendVarint(nil, -5)); !errors.Is(err, ErrNegativeLength) {
+		t.Errorf("期望 ErrNegativeLength，实际: %v", err)
+	}
+}
+
+// TestFrame_Feed 测试一次输入取出所有完整帧
+func TestFrame_Feed(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8}}
+
+	raw := []byte{0x00, 0x01, 'a', 0x00, 0x02, 'b', 'c', 0x00}
+	frames, consumed, err := frame.Feed(raw)
+	if err != nil || consumed != len(raw) || len(frames) != 2 || string(frames[0]) != "a" || string(frames[1]) != "bc" {
+		t.Fatalf("期望取出 a 和 bc，实际: %q, %d, %v", frames, consumed, err)
+	}
+
+	// 剩余的半个头部与下一次输入拼接，出错时返回之前的帧
+	frames, consumed, err = frame.Feed([]byte{0x01, 'd', 0x00, 0x10})
+	if !errors.Is(err, ErrFrameTooLarge) || consumed != 4 || len(frames) != 1 || string(frames[0]) != "d" {
+		t.Errorf("期望取出 d 后返回 ErrFrameTooLarge，实际: %q, %d, %v", frames, consumed, err)
+	}
+}
+
+// TestFrame_Feed_HighWaterMark 测试缓冲区达到上限时只接收部分输入
+func TestFrame_Feed_HighWaterMark(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, HighWaterMark: 4}}
+
+	// 边接收边取帧，总输入超过上限也能全部接收
+	raw := []byte{0x00, 0x01, 'a', 0x00, 0x02, 'b', 'c', 0x00, 0x01, 'd'}
+	frames, consumed, err := frame.Feed(raw)
+	if err != nil || consumed != len(raw) || len(frames) != 3 {
+		t.Fatalf("期望全部接收并取出 3 个帧，实际: %q, %d, %v", frames, consumed, err)
+	}
+
+	// 一个帧放不进缓冲区时只接收到上限为止
+	raw = []byte{0x00, 0x03, 'x', 'y', 'z'}
+	frames, consumed, err = frame.Feed(raw)
+	if !errors.Is(err, ErrHighWaterMark) || consumed != 4 || len(frames) != 0 {
+		t.Errorf("期望接收 4 字节后返回 ErrHighWaterMark，实际: %q, %d, %v", frames, consumed, err)
+	}
+	if buffered := frame.Stats().BytesBuffered; buffered != 4 {
+		t.Errorf("缓冲区不应超过上限，实际: %d", buffered)
+	}
+
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, HighWaterMark: 4}
+	if err := config.Validate(); err == nil {
+		t.Error("HighWaterMark 小于 MaxFrameLength 应校验失败")
+	}
+
+	// HighWaterMark 需要容纳头部 + MaxFrameLength + 尾部
+	for _, tt := range []struct {
+		config *HeaderConfig
+		valid  bool
+	}{
+		{&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, HighWaterMark: 8}, false},
+		{&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, HighWaterMark: 10}, true},
+		{&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, HighWaterMark: 12, Magic: []byte{0xCA, 0xFE}, TrailerLength: 1}, false},
+		{&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, HighWaterMark: 13, Magic: []byte{0xCA, 0xFE}, TrailerLength: 1}, true},
+		{&HeaderConfig{LengthEncoding: LengthVarint, MaxFrameLength: 8, HighWaterMark: 8 + binary.MaxVarintLen64 - 1}, false},
+		{&HeaderConfig{LengthEncoding: LengthVarint, MaxFrameLength: 8, HighWaterMark: 8 + binary.MaxVarintLen64}, true},
+	} {
+		if err := tt.config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("HighWaterMark %d: 期望合法 %v，实际: %v", tt.config.HighWaterMark, tt.valid, err)
+		}
+	}
+}
+
+// TestFrame_Feed_ZeroCopyHighWaterMark 测试 ZeroCopy 时分段接收的多个帧不会被后续输入覆盖
+func TestFrame_Feed_ZeroCopyHighWaterMark(t *testing.T) {
+	frame, _ := NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 3, HighWaterMark: 5, ZeroCopy: true})
+
+	raw := []byte{0, 3, 'a', 'b', 'c', 0, 3, 'x', 'y', 'z', 0, 1, 'q', 0, 2, 'm', 'n'}
+	frames, consumed, err := frame.Feed(raw)
+	if err != nil || consumed != len(raw) {
+		t.Fatalf("期望全部接收，实际: %d, %v", consumed, err)
+	}
+	want := `["abc" "xyz" "q" "mn"]`
+	if got := fmt.Sprintf("%q", frames); got != want {
+		t.Errorf("期望 %s，实际: %s", want, got)
+	}
+}
+
+// TestFrame_Feed_HighWaterMarkDiscard 测试丢弃数据让缓冲区变小之后 Feed 继续接收，不会误报 ErrHighWaterMark
+func TestFrame_Feed_HighWaterMarkDiscard(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *HeaderConfig
+		raw    []byte
+		want   string
+	}{
+		{
+			name:   "跳过空帧",
+			config: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, HighWaterMark: 10, SkipEmptyFrames: true},
+			raw:    []byte{0x00, 0x00, 0x00, 0x08, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h'},
+			want:   `["abcdefgh"]`,
+		},
+		{
+			name:   "丢弃超长帧",
+			config: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 4, HighWaterMark: 6, DropOversized: true},
+			raw:    []byte{0x00, 0x08, '1', '2', '3', '4', '5', '6', '7', '8', 0x00, 0x02, 'h', 'i'},
+			want:   `["hi"]`,
+		},
+		{
+			name:   "重新同步",
+			config: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 4, HighWaterMark: 8, Magic: []byte{0xCA, 0xFE}, Resync: true},
+			raw:    []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0xCA, 0xFE, 0x00, 0x04, 'a', 'b', 'c', 'd'},
+			want:   `["abcd"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := NewFrame(tt.config)
+			if err != nil {
+				t.Fatalf("配置应合法，实际: %v", err)
+			}
+			frames, consumed, err := frame.Feed(tt.raw)
+			if err != nil || consumed != len(tt.raw) {
+				t.Fatalf("期望全部接收，实际: %d, %v", consumed, err)
+			}
+			if got := fmt.Sprintf("%q", frames); got != tt.want {
+				t.Errorf("期望 %s，实际: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFrame_TrimToFit 测试空闲时回收缓冲区容量
+func TestFrame_TrimToFit(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+	packet := append(make([]byte, 2+1000), 0x00, 0x02, 'h')
+	binary.BigEndian.PutUint16(packet, 1000)
+	if body, err := frame.ReadFrame(packet); err != nil || len(body) != 1000 {
+		t.Fatalf("读取大包失败: %d, %v", len(body), err)
+	}
+
+	frame.TrimToFit()
+	if len(frame.buf) != 3 || cap(frame.buf) != 3 {
+		t.Errorf("期望容量恰好为剩余数据长度，实际: len=%d cap=%d", len(frame.buf), cap(frame.buf))
+	}
+	if body, err := frame.ReadFrame([]byte{'i'}); err != nil || string(body) != "hi" {
+		t.Fatalf("收缩后应能继续读取: %q, %v", body, err)
+	}
+
+	frame.TrimToFit()
+	if frame.buf != nil {
+		t.Errorf("缓冲区为空时应完全释放，实际容量: %d", cap(frame.buf))
+	}
+	if body, err := frame.ReadFrame([]byte{0x00, 0x01, 'a'}); err != nil || string(body) != "a" {
+		t.Errorf("释放后应能继续读取: %q, %v", body, err)
+	}
+}
+
+// TestFrame_ReadFrame_CopiesInput 测试 ReadFrame 不保留对 raw 的引用，调用方可以复用读缓冲区
+func TestFrame_ReadFrame_CopiesInput(t *testing.T) {
+	for _, zeroCopy := range []bool{false, true} {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, ZeroCopy: zeroCopy}}
+		readBuf := make([]byte, 4)
+
+		// 半个帧留在缓冲区中时覆盖读缓冲区
+		n := copy(readBuf, []byte{0x00, 0x03, 'a', 'b'})
+		if body, err := frame.ReadFrame(readBuf[:n]); body != nil || err != nil {
+			t.Fatalf("数据不足时应返回 (nil, nil): %v, %v", body, err)
+		}
+		for i := range readBuf {
+			readBuf[i] = 0xFF
+		}
+
+		n = copy(readBuf, []byte{'c'})
+		body, err := frame.ReadFrame(readBuf[:n])
+		if err != nil || string(body) != "abc" {
+			t.Fatalf("ZeroCopy=%v: 覆盖读缓冲区不应影响已经缓冲的数据: %q, %v", zeroCopy, body, err)
+		}
+
+		// 返回的帧也不引用 raw
+		readBuf[0] = 'X'
+		if string(body) != "abc" {
+			t.Errorf("ZeroCopy=%v: 返回的帧不应引用 raw: %q", zeroCopy, body)
+		}
+	}
+}
+
+// tokenLimiter 测试用的限制器，每次 Allow 消耗一个令牌
+type tokenLimiter struct {
+	tokens int
+}
+
+func (l *tokenLimiter) Allow() bool {
+	if l.tokens == 0 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// TestFrame_ReadFrame_RateLimiter 测试超过速率时保留或丢弃帧
+func TestFrame_ReadFrame_RateLimiter(t *testing.T) {
+	data := []byte{0x00, 0x01, 'a', 0x00, 0x01, 'b', 0x00, 0x01, 'c'}
+
+	limiter := &tokenLimiter{tokens: 1}
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, RateLimiter: limiter}}
+	if body, err := frame.ReadFrame(data); err != nil || string(body) != "a" {
+		t.Fatalf("期望 a，实际: %q, %v", body, err)
+	}
+	if _, err := frame.ReadFrame(nil); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("期望 ErrRateLimited，实际: %v", err)
+	}
+	// 默认保留被限速的帧，补充令牌后可以取出
+	limiter.tokens = 1
+	if body, err := frame.ReadFrame(nil); err != nil || string(body) != "b" {
+		t.Errorf("期望补充令牌后取出 b，实际: %q, %v", body, err)
+	}
+
+	limiter = &tokenLimiter{tokens: 1}
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, RateLimiter: limiter, RateLimitDrop: true}}
+	_, _ = frame.ReadFrame(data)
+	if _, err := frame.ReadFrame(nil); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("期望 ErrRateLimited，实际: %v", err)
+	}
+	limiter.tokens = 1
+	if body, err := frame.ReadFrame(nil); err != nil || string(body) != "c" {
+		t.Errorf("RateLimitDrop 时被限速的帧应被丢弃，期望 c，实际: %q, %v", body, err)
+	}
+}
+
+// TestFrame_LengthMask 测试长度字段的高位为标志位时按 LengthShift / LengthMask 取出长度
+func TestFrame_LengthMask(t *testing.T) {
+	// 高 4 位为标志位，低 12 位为长度
+	hc := &HeaderConfig{
+		LengthFieldLength: 2,
+		ByteOrder:         binary.BigEndian,
+		LengthMask:        0x0FFF,
+	}
+	if err := hc.Validate(); err != nil {
+		t.Fatalf("配置应合法，实际: %v", err)
+	}
+
+	f, err := NewFrame(hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := f.ReadFrame([]byte{0xA0, 0x03, 'a', 'b', 'c'})
+	if err != nil || !bytesEqual(body, []byte("abc")) {
+		t.Fatalf("期望 abc，实际: %q, %v", body, err)
+	}
+
+	encoded, err := hc.Encode([]byte("abc"))
+	if err != nil || !bytesEqual(encoded, []byte{0x00, 0x03, 'a', 'b', 'c'}) {
+		t.Fatalf("期望编码为 0003616263，实际: %x, %v", encoded, err)
+	}
+	if _, err := hc.Encode(make([]byte, 0x1000)); err == nil {
+		t.Fatal("body 超过 LengthMask 时应返回错误")
+	}
+
+	// 长度在高 12 位，低 4 位为标志位
+	shifted := &HeaderConfig{
+		LengthFieldLength: 2,
+		ByteOrder:         binary.BigEndian,
+		LengthShift:       4,
+	}
+	g, err := NewFrame(shifted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = g.ReadFrame([]byte{0x00, 0x2F, 'h', 'i'})
+	if err != nil || !bytesEqual(body, []byte("hi")) {
+		t.Fatalf("配置 LengthShift 时期望 hi，实际: %q, %v", body, err)
+	}
+
+	for _, bad := range []*HeaderConfig{
+		{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthMask: 0x1FFFF},
+		{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthShift: 4, LengthMask: 0xFFFF},
+		{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthShift: 16},
+		{LengthEncoding: LengthVarint, LengthMask: 0xFF},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("配置 %+v 应校验失败", bad)
+		}
+	}
+}
+
+// TestFrame_OnRawLength 测试通过 OnRawLength 读取被 LengthMask 屏蔽的标志位
+func TestFrame_OnRawLength(t *testing.T) {
+	// 最高位表示压缩，低 31 位为长度
+	var raws []uint64
+	var lengths []int
+	hc := &HeaderConfig{
+		LengthFieldLength: 4,
+		ByteOrder:         binary.BigEndian,
+		LengthMask:        0x7FFFFFFF,
+		OnRawLength:       func(raw uint64) { raws = append(raws, raw) },
+		OnHeader:          func(length int) { lengths = append(lengths, length) },
+	}
+	f, err := NewFrame(hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte{0x80, 0, 0, 3, 'a', 'b', 'c', 0x00, 0, 0, 2, 'h', 'i'}
+	// 第一个帧的头部先到达
+	if body, err := f.ReadFrame(data[:5]); body != nil || err != nil {
+		t.Fatalf("数据不足应返回 (nil, nil)，实际: %q, %v", body, err)
+	}
+	if len(raws) != 1 || raws[0]&0x80000000 == 0 || lengths[0] != 3 {
+		t.Fatalf("头部到达后期望原始值带压缩标志、长度为 3，实际: %x, %v", raws, lengths)
+	}
+
+	body, err := f.ReadFrame(data[5:])
+	if err != nil || string(body) != "abc" {
+		t.Fatalf("期望 abc，实际: %q, %v", body, err)
+	}
+	if body, err = f.ReadFrame(nil); err != nil || string(body) != "hi" {
+		t.Fatalf("期望 hi，实际: %q, %v", body, err)
+	}
+	if len(raws) != 2 || raws[0] != 0x80000003 || raws[1] != 2 {
+		t.Errorf("期望原始值 [80000003 2]，实际: %x", raws)
+	}
+	if len(lengths) != 2 || lengths[1] != 2 {
+		t.Errorf("期望长度 [3 2]，实际: %v", lengths)
+	}
+}
+
+// TestFrame_ReadFrame_ComputedLengthErrors 测试头部解析成功但算出的长度不合理时返回可区分的错误
+func TestFrame_ReadFrame_ComputedLengthErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config HeaderConfig
+		data   []byte
+		want   error
+	}{
+		{
+			name:   "修正后为负数",
+			config: HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthAdjustment: -4},
+			data:   []byte{0x00, 0x02},
+			want:   ErrNegativeLength,
+		},
+		{
+			name:   "长度包含头部但小于头部",
+			config: HeaderConfig{LengthFieldLength: 4, ByteOrder: binary.BigEndian, LengthIncludesHeader: true},
+			data:   []byte{0x00, 0x00, 0x00, 0x03},
+			want:   ErrNegativeLength,
+		},
+		{
+			name:   "屏蔽标志位后修正为负数",
+			config: HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthMask: 0x0FFF, LengthAdjustment: -2},
+			data:   []byte{0xF0, 0x01},
+			want:   ErrNegativeLength,
+		},
+		{
+			name:   "LengthFunc返回负数",
+			config: HeaderConfig{LengthFieldLength: 1, LengthFunc: func([]byte) (int, error) { return -1, nil }},
+			data:   []byte{0x00},
+			want:   ErrNegativeLength,
+		},
+		{
+			name:   "zigzag解码出负数",
+			config: HeaderConfig{LengthEncoding: LengthVarint, VarintFlavor: VarintZigzag},
+			data:   binary.AppendVarint(nil, -1),
+			want:   ErrNegativeLength,
+		},
+		{
+			name:   "修正后超过上限",
+			config: HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthAdjustment: 10, MaxFrameLength: 16},
+			data:   []byte{0x00, 0x08},
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "按单位换算后超过上限",
+			config: HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthUnitBytes: 4, MaxFrameLength: 16},
+			data:   []byte{0x00, 0x05},
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "修正后小于下限",
+			config: HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, LengthAdjustment: -2, MinFrameLength: 4},
+			data:   []byte{0x00, 0x04},
+			want:   ErrFrameTooSmall,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFrame(&tt.config)
+			if err != nil {
+				t.Fatalf("创建 Frame 失败: %v", err)
+			}
+			_, err = f.ReadFrame(tt.data)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("期望 %v，实际: %v", tt.want, err)
+			}
+			for _, other := range []error{ErrNegativeLength, ErrFrameTooLarge, ErrFrameTooSmall, ErrLengthOverflow} {
+				if other != tt.want && errors.Is(err, other) {
+					t.Errorf("错误 %v 不应同时匹配 %v", err, other)
+				}
+			}
+		})
+	}
+}
+
+// TestFrame_SnapshotRestore 测试退回到快照之后，同一段输入按不同的分块方式输入得到相同的帧
+func TestFrame_SnapshotRestore(t *testing.T) {
+	hc := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian}
+	f, err := NewFrame(hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 快照时缓冲区中已经有半个帧
+	if body, err := f.ReadFrame([]byte{0x00, 0x03, 'a'}); body != nil || err != nil {
+		t.Fatalf("数据不足应返回 (nil, nil)，实际: %q, %v", body, err)
+	}
+	snap := f.Snapshot()
+	if !bytesEqual(snap, []byte{0x00, 0x03, 'a'}) {
+		t.Fatalf("快照内容不匹配，实际: %x", snap)
+	}
+
+	input := []byte{'b', 'c', 0x00, 0x02, 'h', 'i', 0x00, 0x00, 0x00, 0x01}
+	collect := func(chunks [][]byte) []string {
+		f.Restore(snap)
+		var frames []string
+		for _, chunk := range chunks {
+			body, err := f.ReadFrame(chunk)
+			for ; body != nil && err == nil; body, err = f.ReadFrame(nil) {
+				frames = append(frames, string(body))
+			}
+			if err != nil {
+				t.Fatalf("读取出现错误: %v", err)
+			}
+		}
+		return append(frames, fmt.Sprintf("剩余 %x", f.Snapshot()))
+	}
+
+	want := collect([][]byte{input})
+	if len(want) != 4 || want[0] != "abc" || want[1] != "hi" || want[2] != "" {
+		t.Fatalf("期望 [abc hi  剩余 0001]，实际: %q", want)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var chunks [][]byte
+		for rest := input; len(rest) > 0; {
+			n := 1 + rng.Intn(len(rest))
+			chunks, rest = append(chunks, rest[:n]), rest[n:]
+		}
+		if got := collect(chunks); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("分块 %q 的结果不一致，期望: %q，实际: %q", chunks, want, got)
+		}
+	}
+
+	// Restore 不保留对参数的引用
+	buf := []byte{0x00, 0x01, 'z'}
+	f.Restore(buf)
+	buf[2] = 'y'
+	if body, err := f.ReadFrame(nil); err != nil || string(body) != "z" {
+		t.Errorf("期望 z，实际: %q, %v", body, err)
+	}
+}
+
+// TestFrame_SkipEmptyFrames 测试跳过夹在普通帧之间的空帧
+func TestFrame_SkipEmptyFrames(t *testing.T) {
+	hc := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, SkipEmptyFrames: true}
+	var decoded []int
+	hc.OnFrame = func(size int) { decoded = append(decoded, size) }
+	f, err := NewFrame(hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte{0, 0, 0, 0, 0, 2, 'h', 'i', 0, 0, 0, 1, 'x', 0, 0}
+	body, err := f.ReadFrame(data)
+	if err != nil || string(body) != "hi" {
+		t.Fatalf("期望跳过开头的两个空帧返回 hi，实际: %q, %v", body, err)
+	}
+	if body, err = f.ReadFrame(nil); err != nil || string(body) != "x" {
+		t.Fatalf("期望 x，实际: %q, %v", body, err)
+	}
+	// 末尾只剩空帧
+	if body, err = f.ReadFrame(nil); body != nil || err != nil {
+		t.Fatalf("只剩空帧时应返回 (nil, nil)，实际: %q, %v", body, err)
+	}
+	if f.buffered() != 0 {
+		t.Errorf("空帧应被消费，剩余 %d 字节", f.buffered())
+	}
+	if len(decoded) != 2 {
+		t.Errorf("空帧不应触发 OnFrame，实际: %v", decoded)
+	}
+
+	// 带校验和时，只有校验和的帧同样是空帧
+	sum := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, ChecksumLength: 1, ChecksumFunc: func([]byte) uint32 { return 0x5A }, SkipEmptyFrames: true}
+	g, _ := NewFrame(sum)
+	body, err = g.ReadFrame([]byte{0, 1, 0x5A, 0, 2, 'y', 0x5A})
+	if err != nil || string(body) != "y" {
+		t.Errorf("期望跳过只有校验和的帧返回 y，实际: %q, %v", body, err)
+	}
+
+	// 默认仍然返回空切片
+	h, _ := NewFrame(&HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian})
+	if body, err := h.ReadFrame([]byte{0, 0}); body == nil || len(body) != 0 || err != nil {
+		t.Errorf("默认应返回非 nil 的空切片，实际: %v, %v", body, err)
+	}
+}
+
+// TestFrame_Recover 测试头部错误之后重复调用 ReadFrame 的行为，以及通过 Recover 跳过出错的帧
+func TestFrame_Recover(t *testing.T) {
+	t.Run("没有Magic时丢弃超长帧", func(t *testing.T) {
+		f, _ := NewFrame(&HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, MaxFrameLength: 4})
+		if _, err := f.ReadFrame([]byte{0x00, 0x06, 'a', 'b'}); !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+		}
+		// 不恢复时一直返回同样的错误，新的输入仍然保留
+		for i := 0; i < 3; i++ {
+			if body, err := f.ReadFrame([]byte{'c'}); body != nil || !errors.Is(err, ErrFrameTooLarge) {
+				t.Fatalf("第 %d 次重复调用期望 ErrFrameTooLarge，实际: %q, %v", i, body, err)
+			}
+		}
+		if f.buffered() != 7 {
+			t.Fatalf("出错的帧不应被消费，缓冲区: %d 字节", f.buffered())
+		}
+
+		if err := f.Recover(); err != nil {
+			t.Fatal(err)
+		}
+		// 超长帧还差 1 字节，到达后被丢弃，之后的帧正常返回
+		body, err := f.ReadFrame([]byte{'d', 0x00, 0x02, 'o', 'k'})
+		if err != nil || string(body) != "ok" {
+			t.Fatalf("期望 ok，实际: %q, %v", body, err)
+		}
+	})
+
+	t.Run("有Magic时丢弃到下一个Magic", func(t *testing.T) {
+		f, _ := NewFrame(&HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, MaxFrameLength: 4, Magic: []byte{0xCA}})
+		data := []byte{0xCA, 0xFF, 0xFF, 'x', 0xCA, 0x00, 0x02, 'h', 'i'}
+		if _, err := f.ReadFrame(data); !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+		}
+		if err := f.Recover(); err != nil {
+			t.Fatal(err)
+		}
+		body, err := f.ReadFrame(nil)
+		if err != nil || string(body) != "hi" {
+			t.Fatalf("期望 hi，实际: %q, %v", body, err)
+		}
+		if f.ResyncedBytes() != 4 {
+			t.Errorf("期望重新同步时丢弃 4 字节，实际: %d", f.ResyncedBytes())
+		}
+	})
+
+	t.Run("无法恢复的错误", func(t *testing.T) {
+		f, _ := NewFrame(&HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, MinFrameLength: 4})
+		if _, err := f.ReadFrame([]byte{0x00, 0x01, 'a'}); !errors.Is(err, ErrFrameTooSmall) {
+			t.Fatalf("期望 ErrFrameTooSmall，实际: %v", err)
+		}
+		if err := f.Recover(); !errors.Is(err, ErrFrameTooSmall) {
+			t.Errorf("没有 Magic 时期望返回包装了 ErrFrameTooSmall 的错误，实际: %v", err)
+		}
+		if f.buffered() != 3 {
+			t.Errorf("无法恢复时缓冲区应保持不变，实际: %d 字节", f.buffered())
+		}
+	})
+
+	t.Run("没有错误时不做任何事", func(t *testing.T) {
+		f, _ := NewFrame(&HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian})
+		f.ReadFrame([]byte{0x00, 0x02, 'h'})
+		if err := f.Recover(); err != nil || f.buffered() != 3 {
+			t.Errorf("期望保持不变，实际: %d 字节, %v", f.buffered(), err)
+		}
+	})
+}
+
+// TestFrame_ReadFrame_Uint64Length 测试 8 字节长度字段在 math.MaxInt64 附近既不回绕也不截断
+func TestFrame_ReadFrame_Uint64Length(t *testing.T) {
+	u64 := func(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
+	tests := []struct {
+		name   string
+		config HeaderConfig
+		data   []byte
+		want   error
+	}{
+		{
+			name:   "MaxInt64超过上限",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, MaxFrameLength: 1024},
+			data:   u64(math.MaxInt64),
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "MaxInt64+1超过上限",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, MaxFrameLength: 1024},
+			data:   u64(math.MaxInt64 + 1),
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "MaxUint64超过上限",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, MaxFrameLength: math.MaxInt},
+			data:   u64(math.MaxUint64),
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "未设置上限时超出int",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian},
+			data:   u64(math.MaxInt64 + 1),
+			want:   ErrLengthOverflow,
+		},
+		{
+			name:   "未设置上限时加上头部超出int",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian},
+			data:   u64(math.MaxInt64),
+			want:   ErrLengthOverflow,
+		},
+		{
+			name:   "按单位换算后超出uint64",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, LengthUnitBytes: 2, MaxFrameLength: 1024},
+			data:   u64(math.MaxInt64 + 1),
+			want:   ErrLengthOverflow,
+		},
+		{
+			name:   "按单位换算后超过上限",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, LengthUnitBytes: 2, MaxFrameLength: 1024},
+			data:   u64(math.MaxInt64),
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "加上修正量后超出uint64",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, LengthAdjustment: 1, MaxFrameLength: 1024},
+			data:   u64(math.MaxUint64),
+			want:   ErrLengthOverflow,
+		},
+		{
+			name:   "减去修正量后仍超过上限",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, LengthAdjustment: math.MinInt64, MaxFrameLength: 1024},
+			data:   u64(math.MaxUint64),
+			want:   ErrFrameTooLarge,
+		},
+		{
+			name:   "减去修正量后为负数",
+			config: HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.BigEndian, LengthAdjustment: math.MinInt64},
+			data:   u64(math.MaxInt64),
+			want:   ErrNegativeLength,
+		},
+		{
+			name: "Fields中的8字节长度超过上限",
+			config: HeaderConfig{ByteOrder: binary.BigEndian, MaxFrameLength: 1024,
+				Fields: []FieldSpec{{Name: "length", Offset: 0, Width: 8, Length: true}}},
+			data: u64(math.MaxUint64),
+			want: ErrFrameTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFrame(&tt.config)
+			if err != nil {
+				t.Fatalf("创建 Frame 失败: %v", err)
+			}
+			body, err := f.ReadFrame(tt.data)
+			if body != nil || !errors.Is(err, tt.want) {
+				t.Fatalf("期望 %v，实际: %q, %v", tt.want, body, err)
+			}
+		})
+	}
+
+	// 8 字节长度字段的正常帧可以编码后读回
+	config := &HeaderConfig{LengthFieldLength: 8, ByteOrder: binary.LittleEndian, MaxFrameLength: 1024}
+	data, err := config.Encode([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytesEqual(data[:8], binary.LittleEndian.AppendUint64(nil, 5)) {
+		t.Fatalf("长度字段编码错误: %v", data[:8])
+	}
+	f, _ := NewFrame(config)
+	if body, err := f.ReadFrame(data); err != nil || string(body) != "hello" {
+		t.Fatalf("期望 hello，实际: %q, %v", body, err)
+	}
+
+	// ParseUint64 返回完整的无符号值，Parse 超出 int 时报错而不是返回负数
+	config.ByteOrder = binary.BigEndian
+	if v, err := config.ParseUint64(u64(math.MaxUint64)); err != nil || v != math.MaxUint64 {
+		t.Errorf("期望 MaxUint64，实际: %d, %v", v, err)
+	}
+	if _, err := config.Parse(u64(math.MaxInt64 + 1)); err != ErrLengthOverflow {
+		t.Errorf("期望 ErrLengthOverflow，实际: %v", err)
+	}
+}
+
+// TestFrame_FeedTo 测试对每个完整帧调用回调，回调出错时剩余的帧保留在缓冲区中
+func TestFrame_FeedTo(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	f, _ := NewFrame(config)
+
+	var data []byte
+	for _, body := range []string{"a", "bc", "", "def"} {
+		data = append(data, 0, byte(len(body)))
+		data = append(data, body...)
+	}
+	data = append(data, 0, 5, 'x') // 不完整的帧
+
+	var got []string
+	stop := errors.New("stop")
+	err := f.FeedTo(data, func(body []byte) error {
+		got = append(got, string(body))
+		if len(got) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("期望返回回调的错误，实际: %v", err)
+	}
+	if fmt.Sprint(got) != "[a bc]" {
+		t.Fatalf("期望 [a bc]，实际: %q", got)
+	}
+
+	// 剩余的帧在下次调用时继续取出，回调中可以调用 Frame 的方法
+	got = nil
+	err = f.FeedTo([]byte("yzw!"), func(body []byte) error {
+		got = append(got, string(body))
+		f.Stats()
+		return nil
+	})
+	if err != nil || fmt.Sprint(got) != "[ def xyzw!]" {
+		t.Fatalf("期望 [ def xyzw!]，实际: %q, %v", got, err)
+	}
+
+	// 解码错误原样返回，回调不会被调用
+	f, _ = NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 4})
+	err = f.FeedTo([]byte{0, 9}, func([]byte) error {
+		t.Error("超长帧不应调用回调")
+		return nil
+	})
+	if err != ErrFrameTooLarge {
+		t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+}
+
+// TestFrame_WarnFrameLength 测试超过 WarnFrameLength 的帧触发 OnLargeFrame 但照常返回
+func TestFrame_WarnFrameLength(t *testing.T) {
+	var large []int
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		MaxFrameLength:    8,
+		WarnFrameLength:   4,
+		OnLargeFrame:      func(size int) { large = append(large, size) },
+	}
+	f, err := NewFrame(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 超过阈值的帧分两次到达，只在头部到达时触发一次
+	for _, body := range []string{"abcd", "abcdef", "abcdefgh"} {
+		data := append([]byte{0, byte(len(body))}, body...)
+		var got []byte
+		for i := 0; i < len(data) && err == nil; i += 3 {
+			got, err = f.ReadFrame(data[i:min(i+3, len(data))])
+		}
+		if err != nil || string(got) != body {
+			t.Fatalf("期望 %q，实际: %q, %v", body, got, err)
+		}
+	}
+	if fmt.Sprint(large) != "[6 8]" {
+		t.Errorf("期望 OnLargeFrame 收到 [6 8]，实际: %v", large)
+	}
+
+	// 超过 MaxFrameLength 的帧仍然被拒绝，不触发 OnLargeFrame
+	large = nil
+	if _, err := f.ReadFrame([]byte{0, 9}); err != ErrFrameTooLarge {
+		t.Fatalf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if len(large) != 0 {
+		t.Errorf("超长帧不应触发 OnLargeFrame，实际: %v", large)
+	}
+
+	config = &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, MaxFrameLength: 8, WarnFrameLength: 8}
+	if err := config.Validate(); err == nil || err.Error() != "WarnFrameLength must be less than MaxFrameLength" {
+		t.Errorf("期望 WarnFrameLength 不小于 MaxFrameLength 时报错，实际: %v", err)
+	}
+}
+
+// TestFrame_ReadFrame_NilInput 测试 ReadFrame(nil) 只从缓冲区中取出已经完整的帧
+func TestFrame_ReadFrame_NilInput(t *testing.T) {
+	frame, _ := NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2})
+
+	// 缓冲区为空
+	if body, err := frame.ReadFrame(nil); body != nil || err != nil {
+		t.Fatalf("期望 (nil, nil)，实际: %q, %v", body, err)
+	}
+
+	// 一次输入两个完整帧和半个帧，第一次调用只取出第一个
+	data := []byte{0, 1, 'a', 0, 0, 0, 3, 'b'}
+	if body, err := frame.ReadFrame(data); err != nil || string(body) != "a" {
+		t.Fatalf("期望 a，实际: %q, %v", body, err)
+	}
+	// 已经完整到达的空帧返回非 nil 的空切片
+	if body, err := frame.ReadFrame(nil); err != nil || body == nil || len(body) != 0 {
+		t.Fatalf("期望空帧，实际: %q, %v", body, err)
+	}
+	// 只剩不完整的帧，nil 与空切片的行为相同
+	for _, raw := range [][]byte{nil, {}} {
+		if body, err := frame.ReadFrame(raw); body != nil || err != nil {
+			t.Fatalf("期望 (nil, nil)，实际: %q, %v", body, err)
+		}
+	}
+	if n := frame.Stats().BytesBuffered; n != 3 {
+		t.Errorf("期望不完整的帧保留在缓冲区中，实际缓冲 %d 字节", n)
+	}
+	if body, err := frame.ReadFrame([]byte("cd")); err != nil || string(body) != "bcd" {
+		t.Fatalf("期望 bcd，实际: %q, %v", body, err)
+	}
+}
+
+// TestFrame_SetByteOrder 测试在帧边界切换字节序，帧中间切换被拒绝
+func TestFrame_SetByteOrder(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	frame, _ := NewFrame(config)
+
+	if body, err := frame.ReadFrame([]byte{0, 2, 'a', 'b'}); err != nil || string(body) != "ab" {
+		t.Fatalf("期望 ab，实际: %q, %v", body, err)
+	}
+	if err := frame.SetByteOrder(binary.LittleEndian); err != nil {
+		t.Fatalf("帧边界切换字节序失败: %v", err)
+	}
+	if frame.DetectedByteOrder() != binary.LittleEndian {
+		t.Errorf("期望锁定为小端序，实际: %v", frame.DetectedByteOrder())
+	}
+
+	// 半个帧到达之后不能再切换
+	if body, err := frame.ReadFrame([]byte{3}); body != nil || err != nil {
+		t.Fatalf("期望等待更多数据，实际: %q, %v", body, err)
+	}
+	if err := frame.SetByteOrder(binary.BigEndian); err != ErrConfigChangedMidFrame {
+		t.Fatalf("期望 ErrConfigChangedMidFrame，实际: %v", err)
+	}
+	if body, err := frame.ReadFrame([]byte{0, 'c', 'd', 'e'}); err != nil || string(body) != "cde" {
+		t.Fatalf("期望按小端序解析出 cde，实际: %q, %v", body, err)
+	}
+
+	// nil 恢复为 Hc.ByteOrder，Hc 本身没有被修改
+	if err := frame.SetByteOrder(nil); err != nil {
+		t.Fatal(err)
+	}
+	if config.ByteOrder != binary.BigEndian {
+		t.Errorf("SetByteOrder 不应修改 Hc.ByteOrder")
+	}
+	if body, err := frame.ReadFrame([]byte{0, 1, 'f'}); err != nil || string(body) != "f" {
+		t.Fatalf("期望按大端序解析出 f，实际: %q, %v", body, err)
+	}
+}
+
+// TestFrame_DiscardStalePartial 测试长时间没有新数据的半个帧被丢弃，之后的新帧不再错位
+func TestFrame_DiscardStalePartial(t *testing.T) {
+	frame, _ := NewFrame(&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2})
+	if frame.DiscardStalePartial(0) {
+		t.Fatal("缓冲区为空时不应丢弃")
+	}
+
+	// 故障留下的半个帧
+	if body, err := frame.ReadFrame([]byte{0, 5, 'x'}); body != nil || err != nil {
+		t.Fatalf("期望等待更多数据，实际: %q, %v", body, err)
+	}
+	if frame.DiscardStalePartial(time.Hour) {
+		t.Fatal("数据还没有超过 maxAge 时不应丢弃")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !frame.DiscardStalePartial(time.Millisecond) {
+		t.Fatal("期望丢弃超过 maxAge 的半个帧")
+	}
+	if stats := frame.Stats(); stats.BytesBuffered != 0 || stats.BytesConsumed != 3 {
+		t.Errorf("期望缓冲区为空且 3 字节计入 BytesConsumed，实际: %+v", stats)
+	}
+	if body, err := frame.ReadFrame([]byte{0, 2, 'o', 'k'}); err != nil || string(body) != "ok" {
+		t.Fatalf("期望新帧 ok 正常解析，实际: %q, %v", body, err)
+	}
+
+	// 已经完整、还没有被取走的帧不会被丢弃
+	data := []byte{0, 1, 'a', 0, 1, 'b'}
+	if body, err := frame.ReadFrame(data); err != nil || string(body) != "a" {
+		t.Fatalf("期望 a，实际: %q, %v", body, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if frame.DiscardStalePartial(time.Millisecond) {
+		t.Fatal("完整的帧不应被丢弃")
+	}
+	if body, err := frame.ReadFrame(nil); err != nil || string(body) != "b" {
+		t.Fatalf("期望 b，实际: %q, %v", body, err)
+	}
+}
+
+// 辅助函数：比较字节切片
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 基准测试
+func BenchmarkHeaderConfig_Parse2Bytes(b *testing.B) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+	header := []byte{0x01, 0x00}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = config.Parse(header)
+	}
+}
+
+func BenchmarkHeaderConfig_Parse4Bytes(b *testing.B) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 4,
+	}
+	header := []byte{0x00, 0x00, 0x01, 0x00}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = config.Parse(header)
+	}
+}
+
+func BenchmarkFrame_ReadFrame(b *testing.B) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	frame := &Frame{
+		Hc:  config,
+		buf: make([]byte, 0),
+	}
+
+	packet := []byte{0x00, 0x05, 'h', 'e', 'l', 'l', 'o'}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = frame.ReadFrame(packet)
+	}
+}
+
+func BenchmarkFrame_ReadFrame_ByteByByte(b *testing.B) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	frame := &Frame{
+		Hc: config,
+	}
+
+	packet := append([]byte{0x04, 0x00}, make([]byte, 1024)...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// 每次都用新的 Frame，模拟一个大包由很多次小读取拼成的情况
+		frame.buf = nil
+		for j := range packet {
+			_, _ = frame.ReadFrame(packet[j : j+1])
+		}
+	}
+}
+
+func BenchmarkNewFrame_InitialBufferSize(b *testing.B) {
+	packet := append([]byte{0x03, 0xE8}, make([]byte, 1000)...)
+	// 模拟连接建立后前几次读取：每次 64 字节
+	var chunks [][]byte
+	for i := 0; i < len(packet); i += 64 {
+		chunks = append(chunks, packet[i:min(i+64, len(packet))])
+	}
+
+	for _, size := range []int{0, 1024} {
+		b.Run(fmt.Sprintf("InitialBufferSize=%d", size), func(b *testing.B) {
+			config := &HeaderConfig{
+				ByteOrder:         binary.BigEndian,
+				LengthFieldLength: 2,
+				MaxFrameLength:    4096,
+				InitialBufferSize: size,
+			}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				frame, _ := NewFrame(config)
+				for _, chunk := range chunks {
+					_, _ = frame.ReadFrame(chunk)
+				}
+			}
+		})
 	}
 }
@@ -0,0 +1,106 @@
+package frame
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBufferBudgetExceeded FrameSet 中所有流缓冲的数据总量将超过 MaxTotalBuffered
+var ErrBufferBudgetExceeded = errors.New("buffer budget exceeded")
+
+// FrameSet 按连接 ID 为每条流维护独立的 Frame，避免多条流的数据在同一个缓冲区中交错
+type FrameSet[K comparable] struct {
+	Hc *HeaderConfig
+
+	// MaxTotalBuffered 所有流缓冲区中尚未消费的数据总量上限（字节），0 表示不限制
+	// 追加 raw 会使总量超过上限时 ReadFrame 拒绝这次输入并返回 ErrBufferBudgetExceeded，raw 不会被追加；
+	// 检查按追加前的总量加上 len(raw) 计算，调用方通常应该关闭对应的连接并调用 Remove 释放它占用的额度
+	MaxTotalBuffered int
+
+	lock     sync.Mutex
+	frames   map[K]*Frame
+	buffered map[K]int // 每条流上一次 ReadFrame 之后缓冲的字节数
+	total    int       // 所有流缓冲的字节数之和，包括正在处理中的 raw
+}
+
+// NewFrameSet 创建一个所有流共用 hc 配置的 FrameSet
+func NewFrameSet[K comparable](hc *HeaderConfig) *FrameSet[K] {
+	return &FrameSet[K]{
+		Hc:       hc,
+		frames:   make(map[K]*Frame),
+		buffered: make(map[K]int),
+	}
+}
+
+// ReadFrame 把 raw 追加到 id 对应流的缓冲区并尝试取出一个完整帧，语义与 Frame.ReadFrame 相同
+func (s *FrameSet[K]) ReadFrame(id K, raw []byte) ([]byte, error) {
+	f, err := s.reserve(id, len(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := f.ReadFrame(raw)
+	s.settle(id, f, len(raw))
+	return body, err
+}
+
+// Remove 在连接关闭时释放 id 对应流的缓冲区
+func (s *FrameSet[K]) Remove(id K) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.total -= s.buffered[id]
+	delete(s.frames, id)
+	delete(s.buffered, id)
+}
+
+// Len 返回当前管理的流数量
+func (s *FrameSet[K]) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.frames)
+}
+
+// TotalBuffered 返回所有流缓冲区中尚未消费的数据总量
+func (s *FrameSet[K]) TotalBuffered() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.total
+}
+
+// get 返回 id 对应的 Frame，不存在时创建，调用方需持有锁
+func (s *FrameSet[K]) get(id K) *Frame {
+	f, ok := s.frames[id]
+	if !ok {
+		f = newFrame(s.Hc)
+		s.frames[id] = f
+	}
+	return f
+}
+
+// reserve 在追加 n 字节之前检查并预占总量额度，返回 id 对应的 Frame
+func (s *FrameSet[K]) reserve(id K, n int) (*Frame, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.MaxTotalBuffered > 0 && s.total+n > s.MaxTotalBuffered {
+		return nil, ErrBufferBudgetExceeded
+	}
+	s.total += n
+	return s.get(id), nil
+}
+
+// settle 在 ReadFrame 之后用流的实际缓冲量替换 reserve 预占的额度
+func (s *FrameSet[K]) settle(id K, f *Frame, n int) {
+	buffered := f.buffered()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.total -= n
+	if s.frames[id] != f {
+		// 处理期间流已经被 Remove
+		return
+	}
+	s.total += buffered - s.buffered[id]
+	s.buffered[id] = buffered
+}
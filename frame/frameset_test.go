@@ -0,0 +1,121 @@
+package frame
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+)
+
+// TestFrame_SharedAcrossStreams 演示多条流共用一个 Frame 时数据交错导致的错乱
+func TestFrame_SharedAcrossStreams(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	// 两条流各自发送一个被拆成两半的帧，读取顺序交错
+	streamA := [][]byte{{0x00, 0x03, 'a'}, {'a', 'a'}}
+	streamB := [][]byte{{0x00, 0x03, 'b'}, {'b', 'b'}}
+	reads := [][]byte{streamA[0], streamB[0], streamA[1], streamB[1]}
+
+	shared := &Frame{Hc: config}
+	var frames [][]byte
+	for _, raw := range reads {
+		body, err := shared.ReadFrame(raw)
+		if err != nil {
+			break
+		}
+		if body != nil {
+			frames = append(frames, body)
+		}
+	}
+	if len(frames) == 0 || string(frames[0]) == "aaa" {
+		t.Fatalf("期望共用 Frame 解出错乱的帧，实际: %q", frames)
+	}
+	t.Logf("共用 Frame 解出的帧: %q", frames)
+
+	// 使用 FrameSet 按流分开缓冲后结果正确
+	set := NewFrameSet[string](config)
+	ids := []string{"a", "b", "a", "b"}
+	got := map[string]string{}
+	for i, raw := range reads {
+		body, err := set.ReadFrame(ids[i], raw)
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		if body != nil {
+			got[ids[i]] = string(body)
+		}
+	}
+	if got["a"] != "aaa" || got["b"] != "bbb" {
+		t.Errorf("FrameSet 解出的帧不正确: %v", got)
+	}
+}
+
+// TestFrameSet_Concurrent 测试多个连接并发使用同一个 FrameSet
+func TestFrameSet_Concurrent(t *testing.T) {
+	set := NewFrameSet[int](&HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	})
+
+	const numConns = 10
+	const numFrames = 100
+
+	var wg sync.WaitGroup
+	for id := 0; id < numConns; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < numFrames; j++ {
+				// 每个帧拆成两次输入
+				if body, err := set.ReadFrame(id, []byte{0x00, 0x02, byte(id)}); err != nil || body != nil {
+					t.Errorf("连接 %d 第 %d 个帧前半部分: %v, %v", id, j, body, err)
+					return
+				}
+				body, err := set.ReadFrame(id, []byte{byte(j)})
+				if err != nil || !bytesEqual(body, []byte{byte(id), byte(j)}) {
+					t.Errorf("连接 %d 第 %d 个帧不正确: %v, %v", id, j, body, err)
+					return
+				}
+			}
+			set.Remove(id)
+		}(id)
+	}
+	wg.Wait()
+
+	if n := set.Len(); n != 0 {
+		t.Errorf("所有连接移除后应为空，实际: %d", n)
+	}
+}
+
+// TestFrameSet_MaxTotalBuffered 测试所有流共享的缓冲总量上限
+func TestFrameSet_MaxTotalBuffered(t *testing.T) {
+	set := NewFrameSet[int](&HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2})
+	set.MaxTotalBuffered = 8
+
+	// 两条流各缓冲 4 字节的半个帧
+	for id := 0; id < 2; id++ {
+		if _, err := set.ReadFrame(id, []byte{0x00, 0x05, 'a', 'b'}); err != nil {
+			t.Fatalf("流 %d 不期望出现错误: %v", id, err)
+		}
+	}
+	if total := set.TotalBuffered(); total != 8 {
+		t.Fatalf("期望总缓冲 8 字节，实际: %d", total)
+	}
+
+	// 再追加就超过上限，数据不会被追加
+	if _, err := set.ReadFrame(2, []byte{0x00}); err != ErrBufferBudgetExceeded {
+		t.Fatalf("期望 ErrBufferBudgetExceeded，实际: %v", err)
+	}
+
+	// 关闭一条流释放额度后，另一条流可以继续
+	set.Remove(0)
+	body, err := set.ReadFrame(1, []byte{'c', 'd', 'e'})
+	if err != nil || string(body) != "abcde" {
+		t.Fatalf("期望 abcde，实际: %q, %v", body, err)
+	}
+	if total := set.TotalBuffered(); total != 0 {
+		t.Errorf("帧取出后总缓冲应为 0，实际: %d", total)
+	}
+}
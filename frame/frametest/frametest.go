@@ -0,0 +1,31 @@
+// Package frametest 提供编写分帧测试时常用的辅助工具，例如模拟对端分多次发送数据的 io.Reader
+package frametest
+
+import "io"
+
+// chunkedReader 每次 Read 返回一个预设的数据块，见 NewChunkedReader
+type chunkedReader struct {
+	chunks [][]byte
+}
+
+// NewChunkedReader 返回一个按 chunks 的划分依次返回数据的 io.Reader，用于模拟 TCP 的分包和粘包
+// - 每次 Read 最多返回一个数据块，p 放不下时剩余部分留给下一次 Read
+// - 空的数据块会返回 (0, nil)，可以用来模拟一次没有数据的读取
+// - 所有数据块读完之后返回 io.EOF
+//
+// 不会修改 chunks 中的数据
+func NewChunkedReader(chunks ...[]byte) io.Reader {
+	return &chunkedReader{chunks: append([][]byte(nil), chunks...)}
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
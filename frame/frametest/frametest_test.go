@@ -0,0 +1,58 @@
+package frametest
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"go-toolkit/frame"
+)
+
+// TestNewChunkedReader 测试按数据块依次返回数据
+func TestNewChunkedReader(t *testing.T) {
+	chunks := [][]byte{[]byte("abc"), {}, []byte("d")}
+	r := NewChunkedReader(chunks...)
+
+	p := make([]byte, 2)
+	var got []string
+	for {
+		n, err := r.Read(p)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(p[:n]))
+	}
+
+	want := []string{"ab", "c", "", "d"}
+	if len(got) != len(want) {
+		t.Fatalf("期望 %q，实际: %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("期望 %q，实际: %q", want, got)
+		}
+	}
+	if string(chunks[0]) != "abc" {
+		t.Errorf("不应修改调用方的数据块: %q", chunks[0])
+	}
+}
+
+// TestNewChunkedReader_FrameReader 测试配合 FrameReader 模拟任意的分包方式
+func TestNewChunkedReader_FrameReader(t *testing.T) {
+	r := NewChunkedReader([]byte{0x00}, []byte{0x03, 'a'}, []byte{'b', 'c', 0x00, 0x01}, []byte{'d'})
+	fr := frame.NewReader(r, &frame.HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2})
+
+	for _, want := range []string{"abc", "d"} {
+		body, err := fr.Next()
+		if err != nil || string(body) != want {
+			t.Fatalf("期望 %q，实际: %q, %v", want, body, err)
+		}
+	}
+	if _, err := fr.Next(); err != io.EOF {
+		t.Errorf("期望 io.EOF，实际: %v", err)
+	}
+}
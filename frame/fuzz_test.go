@@ -0,0 +1,108 @@
+package frame
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// fuzzConfig 用 fuzz 输入开头的几个字节选出一个 HeaderConfig，覆盖长度字段宽度、字节序、Fields、头部校验和、各种修正和帧尾
+func fuzzConfig(b []byte) *HeaderConfig {
+	var p [9]byte
+	copy(p[:], b)
+
+	hc := &HeaderConfig{
+		LengthFieldLength:    []int{2, 4}[p[0]&1],
+		ByteOrder:            []binary.ByteOrder{binary.BigEndian, binary.LittleEndian}[p[0]>>1&1],
+		LengthEncoding:       []LengthEncoding{LengthFixed, LengthFixed, LengthVarint, LengthASCIIDecimal}[p[0]>>2&3],
+		LengthIncludesHeader: p[0]>>4&1 == 1,
+		LengthFieldOffset:    int(p[1] & 3),
+		TrailerLength:        int(p[1] >> 2 & 3),
+		InitialBytesToStrip:  int(p[1]>>4&3) - 1,
+		MaxFrameLength:       int(p[2]) << (p[3] & 15),
+		MinFrameLength:       int(p[4] & 3),
+		ReturnFullFrame:      p[4]>>2&1 == 1,
+		DropOversized:        p[4]>>3&1 == 1,
+		LengthUnitBytes:      int(p[4] >> 4 & 3),
+		LengthMask:           uint64(p[6]&0xF) << 8,
+		LengthShift:          uint(p[6] >> 4 & 3),
+	}
+	if !hc.LengthIncludesHeader {
+		hc.LengthAdjustment = int(int8(p[5]))
+	}
+	if p[7]&1 == 1 {
+		hc.Magic = []byte{0xA5, p[7]}
+		hc.Resync = p[7]&2 != 0
+	}
+	if p[7]&4 != 0 {
+		hc.ChecksumLength = 4
+		hc.ChecksumFunc = crc32.ChecksumIEEE
+	}
+	if p[7]&8 != 0 {
+		hc.VarintFlavor = VarintZigzag
+	}
+	if p[8]&1 != 0 {
+		hc.Fields = []FieldSpec{
+			{Name: "type", Offset: 0, Width: 1},
+			{Name: "length", Offset: 1, Width: hc.LengthFieldLength, Length: true},
+		}
+	}
+	if p[8]&2 != 0 {
+		hc.HeaderChecksum = &HeaderChecksum{Offset: int(p[8] >> 2 & 7), Width: 1, Func: XORChecksum}
+	}
+	if hc.LengthMask != 0 {
+		hc.LengthMask |= 0xFF
+	}
+	return hc
+}
+
+// FuzzReadFrame 用随机的配置和随机的分块方式输入任意数据，ReadFrame 只能返回错误，不能 panic
+// SplitFrames、SplitFunc 和 ReadFrameFromBufio 共用同一套头部解析，也用同样的输入检查一遍
+func FuzzReadFrame(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0}, []byte{0x00, 0x02, 'h', 'i'}, uint8(1))
+	f.Add([]byte{1, 0, 0, 0, 0, 0, 0, 0}, []byte{0xFF, 0xFF, 0xFF, 0xFF, 'x'}, uint8(3))
+	f.Add([]byte{0x11, 0x05, 0x10, 0x01, 0x07, 0xF0, 0, 0x05}, []byte{0xA5, 0x05, 0, 0, 0, 0x3, 'a', 'b', 'c'}, uint8(2))
+	f.Add([]byte{0x08, 0, 0, 0, 0, 0, 0, 0x08}, []byte{0x01, '5', '\n', 'a'}, uint8(0))
+
+	f.Fuzz(func(t *testing.T, config, input []byte, step uint8) {
+		hc := fuzzConfig(config)
+		if hc.Validate() != nil {
+			return
+		}
+		fr, err := NewFrame(hc)
+		if err != nil {
+			return
+		}
+
+		chunk := int(step%16) + 1
+		for data := input; len(data) > 0; {
+			n := min(chunk, len(data))
+			body, err := fr.ReadFrame(data[:n])
+			data = data[n:]
+			// 每个字节最多取出一个帧（空帧至少也有头部），循环一定会结束
+			for i := 0; body != nil && err == nil && i <= n+len(data); i++ {
+				body, err = fr.ReadFrame(nil)
+			}
+			if err != nil {
+				// Magic 不匹配时不消费数据，跳过之后继续
+				fr.SkipToMagic()
+			}
+		}
+		fr.DrainAll()
+		fr.PendingFrameLength()
+		SplitFrames(input, hc)
+
+		sc := bufio.NewScanner(bytes.NewReader(input))
+		sc.Split(SplitFunc(hc))
+		for sc.Scan() {
+		}
+		br := bufio.NewReaderSize(bytes.NewReader(input), 16)
+		for i := 0; i <= len(input); i++ {
+			if _, err := ReadFrameFromBufio(br, hc); err != nil {
+				break
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrHeaderChecksumMismatch 帧头部的校验和不匹配，说明头部（包括长度字段）已经损坏，不能信任其中的长度
+var ErrHeaderChecksumMismatch = errors.New("header checksum mismatch")
+
+// HeaderChecksum 头部中只覆盖头部本身的校验和，见 HeaderConfig.HeaderChecksum
+type HeaderChecksum struct {
+	Offset int                        // 校验和相对 Magic 之后的偏移，通常紧跟在长度字段之后
+	Width  int                        // 校验和占用字节数（1、2 或 4），按 HeaderConfig.ByteOrder 编码
+	Func   func(header []byte) uint32 // 计算校验和，参数为从帧开头（包含 Magic）到校验和之前的所有字节，结果截断到 Width 字节
+}
+
+// XORChecksum 把所有字节异或在一起，是最常见的 1 字节头部校验和
+func XORChecksum(header []byte) uint32 {
+	var sum byte
+	for _, b := range header {
+		sum ^= b
+	}
+	return uint32(sum)
+}
+
+// validate 检查 HeaderChecksum 是否合法，order 为 HeaderConfig.ByteOrder
+func (hcs *HeaderChecksum) validate(order binary.ByteOrder) error {
+	if hcs.Func == nil {
+		return errors.New("HeaderChecksum.Func is required")
+	}
+	if hcs.Offset < 0 {
+		return errors.New("HeaderChecksum.Offset must not be negative")
+	}
+	switch hcs.Width {
+	case 1:
+	case 2, 4:
+		if order == nil {
+			return errors.New("ByteOrder is required")
+		}
+	default:
+		return errors.New("unsupported HeaderChecksum.Width, only 1, 2 or 4")
+	}
+	return nil
+}
+
+// sum 计算 header（校验和之前的字节）的校验和并截断到 Width 字节
+func (hcs *HeaderChecksum) sum(header []byte) uint32 {
+	sum := hcs.Func(header)
+	switch hcs.Width {
+	case 1:
+		return sum & 0xFF
+	case 2:
+		return sum & 0xFFFF
+	default:
+		return sum
+	}
+}
+
+// verifyHeaderChecksum 校验 buf 开头的头部校验和，返回头部至少需要的长度（到校验和结束为止）
+// 数据不足时 ok 为 false
+func (hc *HeaderConfig) verifyHeaderChecksum(buf []byte) (end int, ok bool, err error) {
+	hcs := hc.HeaderChecksum
+	start := len(hc.Magic) + hcs.Offset
+	end = start + hcs.Width
+	if len(buf) < end {
+		return 0, false, nil
+	}
+
+	var actual uint32
+	b := buf[start:end]
+	switch hcs.Width {
+	case 1:
+		actual = uint32(b[0])
+	case 2:
+		actual = uint32(hc.ByteOrder.Uint16(b))
+	case 4:
+		actual = hc.ByteOrder.Uint32(b)
+	}
+	if hcs.sum(buf[:start]) != actual {
+		return 0, false, ErrHeaderChecksumMismatch
+	}
+	return end, true, nil
+}
+
+// skipCorruptHeader 在头部损坏（头部校验和不匹配、长度不合理等）时丢弃当前这个 Magic，重新同步到下一个 Magic，调用方需持有锁
+func (f *Frame) skipCorruptHeader() {
+	f.buf = f.buf[1:]
+	f.resynced++
+	f.consumed++
+	f.committed = false
+	f.skipToMagic()
+}
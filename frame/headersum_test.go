@@ -0,0 +1,55 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestFrame_ReadFrame_HeaderChecksum 测试在信任长度字段之前校验头部校验和
+func TestFrame_ReadFrame_HeaderChecksum(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		Magic:             []byte{0xAA},
+		HeaderChecksum:    &HeaderChecksum{Offset: 2, Width: 1, Func: XORChecksum},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := config.Encode([]byte("hi"))
+	expected := []byte{0xAA, 0x00, 0x02, 0xAA ^ 0x02, 'h', 'i'}
+	if err != nil || !bytesEqual(encoded, expected) {
+		t.Fatalf("编码结果不匹配，期望: %v, 实际: %v, %v", expected, encoded, err)
+	}
+	frame := &Frame{Hc: config}
+	if body, err := frame.ReadFrame(encoded); err != nil || string(body) != "hi" {
+		t.Fatalf("期望 hi，实际: %q, %v", body, err)
+	}
+
+	// 长度字段被破坏成 0xFFFF，在缓冲 body 之前就应该发现
+	corrupt := []byte{0xAA, 0xFF, 0xFF, 0xAA ^ 0x02}
+	if _, err := frame.ReadFrame(corrupt); !errors.Is(err, ErrHeaderChecksumMismatch) {
+		t.Fatalf("期望 ErrHeaderChecksumMismatch，实际: %v", err)
+	}
+	if cap(frame.buf) >= 0xFFFF {
+		t.Errorf("校验失败的头部不应触发预分配，实际容量: %d", cap(frame.buf))
+	}
+
+	// 开启 Resync 时跳过损坏的头部，继续解析下一个帧
+	config.Resync = true
+	frame = &Frame{Hc: config}
+	body, err := frame.ReadFrame(append(corrupt, encoded...))
+	if err != nil || string(body) != "hi" {
+		t.Fatalf("期望重新同步后读到 hi，实际: %q, %v", body, err)
+	}
+	if resynced := frame.ResyncedBytes(); resynced != uint64(len(corrupt)) {
+		t.Errorf("期望丢弃 %d 字节，实际: %d", len(corrupt), resynced)
+	}
+
+	bad := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, HeaderChecksum: &HeaderChecksum{Width: 1}}
+	if err := bad.Validate(); err == nil {
+		t.Error("缺少 Func 应校验失败")
+	}
+}
@@ -0,0 +1,55 @@
+package frame
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONFrameReader 从数据流中逐个读取帧，并把每个帧的 body 作为一个 JSON 值解码成 T，见 NewJSONReader
+type JSONFrameReader[T any] struct {
+	fr *FrameReader
+}
+
+// JSONDecodeError 帧已经完整读出，但 body 不是合法的 T 类型 JSON 时由 JSONFrameReader.Decode 返回
+// 分帧本身的错误（io.EOF、ErrFrameTooLarge 等）原样返回，不会包装成 JSONDecodeError，
+// 因此可以通过 errors.As 区分是对端发来了错误的 JSON 还是数据流本身出了问题。
+// 解码错误之后数据流仍然按帧对齐，可以继续调用 Decode 读取下一个帧
+type JSONDecodeError struct {
+	Body []byte // 解码失败的帧的 body
+	Err  error  // json.Unmarshal 返回的错误，例如 *json.SyntaxError
+}
+
+func (e *JSONDecodeError) Error() string {
+	return fmt.Sprintf("decode JSON frame of %d bytes: %v", len(e.Body), e.Err)
+}
+
+func (e *JSONDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// NewJSONReader 创建一个从 r 读取、按 hc 分帧、每个帧的 body 是一个 JSON 值的 JSONFrameReader
+func NewJSONReader[T any](r io.Reader, hc *HeaderConfig) *JSONFrameReader[T] {
+	return &JSONFrameReader[T]{fr: NewReader(r, hc)}
+}
+
+// Decode 读取下一个帧并把 body 解码成 T
+// - 分帧的错误与 FrameReader.Next 相同，数据流在帧边界结束时返回 io.EOF
+// - body 解码失败时返回 *JSONDecodeError 和 T 的零值
+func (jr *JSONFrameReader[T]) Decode() (T, error) {
+	var v T
+	body, err := jr.fr.Next()
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		var zero T
+		return zero, &JSONDecodeError{Body: body, Err: err}
+	}
+	return v, nil
+}
+
+// Reader 返回底层的 FrameReader，可以用来设置 FrameTimeout、StrictEOF 等选项
+func (jr *JSONFrameReader[T]) Reader() *FrameReader {
+	return jr.fr
+}
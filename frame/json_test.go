@@ -0,0 +1,49 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+type jsonMessage struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// TestJSONFrameReader 测试按帧解码 JSON，以及区分分帧错误和 JSON 错误
+func TestJSONFrameReader(t *testing.T) {
+	config := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian}
+	var data []byte
+	for _, body := range []string{`{"id":1,"name":"a"}`, `{"id":`, `{"id":2,"name":"b"}`} {
+		data, _ = config.AppendFrame(data, []byte(body))
+	}
+	data = append(data, 0x00, 0x05, '{') // 截断的帧
+
+	jr := NewJSONReader[jsonMessage](bytes.NewReader(data), config)
+	if msg, err := jr.Decode(); err != nil || msg != (jsonMessage{1, "a"}) {
+		t.Fatalf("期望 {1 a}，实际: %+v, %v", msg, err)
+	}
+
+	_, err := jr.Decode()
+	var decodeErr *JSONDecodeError
+	if !errors.As(err, &decodeErr) || string(decodeErr.Body) != `{"id":` {
+		t.Fatalf("期望 *JSONDecodeError，实际: %v", err)
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("*JSONDecodeError 应包装 json.Unmarshal 的错误，实际: %v", decodeErr.Err)
+	}
+
+	// 解码错误之后仍然可以继续读取
+	if msg, err := jr.Decode(); err != nil || msg != (jsonMessage{2, "b"}) {
+		t.Fatalf("期望 {2 b}，实际: %+v, %v", msg, err)
+	}
+	_, err = jr.Decode()
+	if err != io.ErrUnexpectedEOF || errors.As(err, &decodeErr) {
+		t.Errorf("截断的帧应原样返回 io.ErrUnexpectedEOF，实际: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package frame
+
+import (
+	"errors"
+	"sync"
+)
+
+// PriorityFrame 在同一条数据流中优先取出控制帧，用于控制帧（类型字段中的标志位为 1）需要尽快处理的多路复用协议
+//
+// 字节流中的帧只能首尾相接，控制帧不可能出现在一个还没有收齐的数据帧的字节中间，
+// 因此这种模式要求对端在帧边界上插入控制帧：大的数据需要拆成多个较小的数据帧发送，控制帧插在它们之间。
+// PriorityFrame 每次输入数据后解码缓冲区中所有完整的帧，控制帧排在所有尚未取走的数据帧之前返回，
+// 调用方正在处理一批数据帧时，后到达的控制帧不必排在它们后面。控制帧之间、数据帧之间各自保持到达的顺序
+type PriorityFrame struct {
+	frame   *Frame
+	field   FieldSpec
+	mask    uint64
+	control [][]byte // 已经解码、尚未取走的控制帧
+	data    [][]byte // 已经解码、尚未取走的数据帧
+	err     error    // 解码时遇到的错误，在队列中的帧取完之后返回
+	lock    sync.Mutex
+}
+
+// NewPriorityFrame 创建一个按 hc 解码的 PriorityFrame
+// field 描述类型字段在头部中的位置（与 NewDispatcher 相同，Offset 从 Magic 之后开始计算），
+// 类型字段的值与 mask 按位与不为 0 的帧是控制帧。不支持 ZeroCopy，因为排队的帧不能引用会被覆盖的内部缓冲区
+func NewPriorityFrame(hc *HeaderConfig, field FieldSpec, mask uint64) (*PriorityFrame, error) {
+	f, err := NewFrame(hc)
+	if err != nil {
+		return nil, err
+	}
+	if err := hc.validateField(field); err != nil {
+		return nil, err
+	}
+	if mask == 0 {
+		return nil, errors.New("control mask must not be zero")
+	}
+	if hc.ZeroCopy {
+		return nil, errors.New("PriorityFrame does not support ZeroCopy")
+	}
+	return &PriorityFrame{frame: f, field: field, mask: mask}, nil
+}
+
+// ReadFrame 输入一次读到的数据，返回下一个帧以及它是否为控制帧
+// - 有已经到达的控制帧时总是先返回控制帧，然后才返回在它之前到达的数据帧
+// - 没有完整的帧时返回 (nil, false, nil)，等待下次补充；队列中还有帧时调用方应继续调用 ReadFrame（raw 传 nil）
+// - 解码出错时，队列中已有的帧先返回，错误在它们取完之后返回
+func (p *PriorityFrame) ReadFrame(raw []byte) (body []byte, control bool, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.fill(raw); err != nil && p.err == nil {
+		p.err = err
+	}
+	switch {
+	case len(p.control) > 0:
+		body, p.control = p.control[0], p.control[1:]
+		return body, true, nil
+	case len(p.data) > 0:
+		body, p.data = p.data[0], p.data[1:]
+		return body, false, nil
+	default:
+		err, p.err = p.err, nil
+		return nil, false, err
+	}
+}
+
+// Buffered 返回已经解码、尚未取走的控制帧和数据帧的个数
+func (p *PriorityFrame) Buffered() (control, data int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.control), len(p.data)
+}
+
+// fill 把 raw 交给 Frame，解码出缓冲区中所有完整的帧并按类型放入队列，调用方需持有 p.lock
+func (p *PriorityFrame) fill(raw []byte) error {
+	f := p.frame
+	for {
+		f.lock.Lock()
+		rf, body, err := f.readFrameRaw(raw)
+		var typ uint64
+		if body != nil {
+			header := rf.header[len(f.Hc.Magic):]
+			if p.field.Offset+p.field.Width > len(header) {
+				body, err = nil, errors.New("field outside header")
+			} else {
+				typ = p.field.value(header, f.byteOrder())
+			}
+		}
+		f.lock.Unlock()
+		f.notify(body, err)
+		raw = nil
+
+		if err != nil || body == nil {
+			return err
+		}
+		if typ&p.mask != 0 {
+			p.control = append(p.control, body)
+		} else {
+			p.data = append(p.data, body)
+		}
+	}
+}
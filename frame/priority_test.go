@@ -0,0 +1,79 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestPriorityFrame 测试控制帧排在尚未取走的数据帧之前返回
+func TestPriorityFrame(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:      binary.BigEndian,
+		MaxFrameLength: 16,
+		Fields: []FieldSpec{
+			{Name: "type", Offset: 0, Width: 1},
+			{Name: "length", Offset: 1, Width: 2, Length: true},
+		},
+	}
+	p, err := NewPriorityFrame(config, FieldSpec{Name: "type", Offset: 0, Width: 1}, 0x80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := func(typ byte, body string) []byte {
+		return append([]byte{typ, 0, byte(len(body))}, body...)
+	}
+
+	// 两个数据帧之后紧跟一个控制帧，外加下一个数据帧的前半部分
+	var data []byte
+	data = append(data, frame(0x01, "d1")...)
+	data = append(data, frame(0x01, "d2")...)
+	data = append(data, frame(0x81, "ping")...)
+	data = append(data, frame(0x01, "d3")[:2]...)
+
+	type result struct {
+		body    string
+		control bool
+	}
+	read := func(raw []byte) result {
+		t.Helper()
+		body, control, err := p.ReadFrame(raw)
+		if err != nil || body == nil {
+			t.Fatalf("期望取出一个帧，实际: %q, %v", body, err)
+		}
+		return result{string(body), control}
+	}
+
+	if got := read(data); got != (result{"ping", true}) {
+		t.Fatalf("期望先返回控制帧 ping，实际: %+v", got)
+	}
+	if got := read(nil); got != (result{"d1", false}) {
+		t.Fatalf("期望 d1，实际: %+v", got)
+	}
+	// 还有 d2 没有取走时到达了新的控制帧
+	rest := append(frame(0x01, "d3")[2:], frame(0x82, "stop")...)
+	if got := read(rest); got != (result{"stop", true}) {
+		t.Fatalf("期望控制帧 stop 排在 d2 之前，实际: %+v", got)
+	}
+	for _, want := range []string{"d2", "d3"} {
+		if got := read(nil); got != (result{want, false}) {
+			t.Fatalf("期望 %s，实际: %+v", want, got)
+		}
+	}
+	if body, _, err := p.ReadFrame(nil); body != nil || err != nil {
+		t.Fatalf("队列为空时应返回 (nil, false, nil)，实际: %q, %v", body, err)
+	}
+
+	// 出错之前已经到达的帧先返回
+	data = append(frame(0x01, "ok"), 0x01, 0xFF, 0xFF)
+	if got := read(data); got != (result{"ok", false}) {
+		t.Fatalf("期望 ok，实际: %+v", got)
+	}
+	if _, _, err := p.ReadFrame(nil); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+
+	if _, err := NewPriorityFrame(config, FieldSpec{Name: "type", Width: 1}, 0); err == nil {
+		t.Error("mask 为 0 应返回错误")
+	}
+}
@@ -0,0 +1,446 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const defaultReadBufferSize = 4096
+
+// ErrIncompleteFrameTimeout 未完成的帧在 FrameReader.FrameTimeout 内没有收齐
+var ErrIncompleteFrameTimeout = errors.New("incomplete frame timeout")
+
+// FrameReader 从 io.Reader 中连续解码完整帧，内部复用 Frame 的缓冲逻辑
+//
+// FrameReader 同一时间只能由一个 goroutine 读取。它与包装同一个 conn 的 FrameWriter 之间没有共享的可变状态
+// （HeaderConfig 只会被读取），因此可以一个 goroutine 调用 Next、另一个 goroutine 调用 WriteFrame；
+// NextCtx 打断读取时只修改读超时，不影响写
+type FrameReader struct {
+	// FrameTimeout 缓冲区中有未完成的帧时，从上一个帧完成（或者这个帧的第一个字节到达）起，
+	// 超过这个时间仍未收齐就返回 ErrIncompleteFrameTimeout，用于对付只发半个头部就停住的慢速连接；0 表示不限制
+	// 缓冲区为空时不计时，因此不影响空闲的长连接。与 NextCtx 相同，只有底层支持 SetReadDeadline 时才能打断阻塞中的 Read
+	FrameTimeout time.Duration
+
+	// Tap 不为 nil 时，每解码出一个帧就把它完整的原始字节（头部 + body + 校验和 + 尾部）写入 Tap，
+	// 用于抓包排查。抓包文件就是原始帧的简单拼接，回放时用同样的 HeaderConfig 对它调用 NewReplayReader。
+	// 写入 Tap 失败时 Next 返回这个错误，对应的帧被丢弃
+	Tap io.Writer
+
+	// StrictEOF 数据流在帧中间结束时返回 *TrailingDataError 而不是单纯的 io.ErrUnexpectedEOF，
+	// 其中带有缓冲区中剩余的字节数，用于区分对端截断了一个帧还是在末尾写入了垃圾数据。
+	// *TrailingDataError 包装 io.ErrUnexpectedEOF，errors.Is(err, io.ErrUnexpectedEOF) 在两种模式下都成立
+	StrictEOF bool
+
+	// HandshakeSpec 不为 nil 时，数据流开头是一个只出现一次、格式与普通帧不同的握手帧，
+	// 必须先调用 Handshake 读出它，之后 Next 才按 NewReader 的 HeaderConfig 读取普通帧。需要在第一次读取之前设置
+	HandshakeSpec *HandshakeSpec
+
+	// Separator WriteTo 在每个 body 之后写入的分隔符，默认为空，即 body 首尾相接
+	Separator []byte
+
+	r            io.Reader
+	frame        *Frame
+	scratch      []byte
+	err          error     // 底层 Reader 返回的错误，在缓冲区中的帧取完后再返回
+	pendingSince time.Time // 未完成帧的计时起点，零值表示缓冲区为空
+
+	whole     []byte // 没有长度字段时累积的整个数据流，见 NewReader
+	wholeDone bool   // 没有长度字段时是否已经返回过唯一的帧
+
+	handshakeDone bool  // 是否已经读出握手帧
+	handshakeErr  error // 读取握手帧失败的错误，之后的 Handshake / Next 都返回这个错误
+}
+
+// ErrHandshakeNotRead 配置了 FrameReader.HandshakeSpec，但在调用 Handshake 之前调用了 Next
+var ErrHandshakeNotRead = errors.New("handshake not read, call Handshake first")
+
+// HandshakeSpec 描述数据流开头的握手帧，Length 和 Header 必须设置且只能设置其中一个
+type HandshakeSpec struct {
+	Length int           // 定长的握手帧，按这个字节数整体返回
+	Header *HeaderConfig // 按另一套头部配置解析的握手帧，返回的内容与 Frame.ReadFrame 相同
+}
+
+// TrailingDataError StrictEOF 时数据流在帧中间结束返回的错误，可通过 errors.Is(err, io.ErrUnexpectedEOF) 判断
+type TrailingDataError struct {
+	Leftover int // 缓冲区中没有组成完整帧的字节数
+}
+
+func (e *TrailingDataError) Error() string {
+	return fmt.Sprintf("unexpected EOF: %d bytes left over after the last complete frame", e.Leftover)
+}
+
+func (e *TrailingDataError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// deadlineReader 支持读超时的 Reader，例如 net.Conn
+type deadlineReader interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// NewReader 创建一个从 r 读取、按 hc 解码的 FrameReader
+//
+// hc 为 LengthFixed 且 LengthFieldLength 为 0（也没有配置 Fields / LengthFunc）时表示没有长度字段：
+// 一直读取到底层 Reader 返回 io.EOF，把读到的全部数据作为唯一的一个帧返回，之后返回 io.EOF，
+// 适合对端回复后就关闭连接的一问一答场景。配置了 MaxFrameLength 时超过上限返回 ErrFrameTooLarge。
+// 这种模式只有 FrameReader 支持，Frame.ReadFrame 无法知道数据流何时结束，仍然会返回错误
+func NewReader(r io.Reader, hc *HeaderConfig) *FrameReader {
+	return NewReaderSize(r, hc, defaultReadBufferSize)
+}
+
+// NewReplayReader 创建一个回放 Tap 抓包文件的 FrameReader，hc 应与抓包时使用的 HeaderConfig 相同，
+// 抓包中的帧按正常的解码流程（校验和、回调等）依次由 Next 返回，全部回放完之后返回 io.EOF。
+// 抓包文件只包含完整的帧，因此开启了 StrictEOF：文件在帧中间结束（例如抓包进程被杀死）时返回 *TrailingDataError
+func NewReplayReader(capture io.Reader, hc *HeaderConfig) *FrameReader {
+	fr := NewReader(capture, hc)
+	fr.StrictEOF = true
+	return fr
+}
+
+// NewReaderSize 与 NewReader 相同，但每次从 r 最多读取 size 字节，size 不大于 0 时使用默认的 4096
+// 缓冲区中已经完整的帧总是先取完再读取下一批数据，读取慢速 Reader 时较大的 size 可以减少 Read 的调用次数
+func NewReaderSize(r io.Reader, hc *HeaderConfig, size int) *FrameReader {
+	if size <= 0 {
+		size = defaultReadBufferSize
+	}
+	return &FrameReader{
+		r:       r,
+		frame:   newFrame(hc),
+		scratch: make([]byte, size),
+	}
+}
+
+// Next 返回下一个完整帧，必要时从底层 Reader 读取更多数据
+// - 数据流恰好在帧边界结束时返回 io.EOF
+// - 数据流在帧中间结束时返回 io.ErrUnexpectedEOF，设置了 StrictEOF 时返回 *TrailingDataError
+func (fr *FrameReader) Next() ([]byte, error) {
+	return fr.NextCtx(context.Background())
+}
+
+// NextCtx 与 Next 相同，但在等待数据时响应 ctx 的取消和超时，返回 ctx.Err()
+//
+// 只有底层 Reader 支持 SetReadDeadline（例如 net.Conn）时才能打断已经阻塞的 Read：
+// ctx 结束时会把读超时设置为过去的时间，Read 返回后再清除读超时，
+// 因此调用方在 conn 上自行设置的读超时会被覆盖。
+// 对于其他 Reader，只能在两次 Read 之间检查 ctx，阻塞中的 Read 需要调用方关闭 Reader 来打断。
+// 被 ctx 打断后已缓冲的数据不会丢失，可以继续调用 Next/NextCtx。
+func (fr *FrameReader) NextCtx(ctx context.Context) ([]byte, error) {
+	_, body, err := fr.next(ctx, false)
+	return body, err
+}
+
+// next 是 NextCtx 的实现，withHeader 为 true 时同时返回帧头部的拷贝
+func (fr *FrameReader) next(ctx context.Context, withHeader bool) ([]byte, []byte, error) {
+	if fr.HandshakeSpec != nil && !fr.handshakeDone {
+		if fr.handshakeErr != nil {
+			return nil, nil, fr.handshakeErr
+		}
+		return nil, nil, ErrHandshakeNotRead
+	}
+	if fr.readsWhole() {
+		body, err := fr.nextWhole(ctx)
+		return nil, body, err
+	}
+
+	for {
+		header, body, err := fr.decode(nil, withHeader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if body != nil {
+			fr.track(true)
+			return header, body, nil
+		}
+
+		if fr.err != nil {
+			if n := fr.frame.buffered(); fr.err == io.EOF && n > 0 {
+				if fr.StrictEOF {
+					return nil, nil, &TrailingDataError{Leftover: n}
+				}
+				return nil, nil, io.ErrUnexpectedEOF
+			}
+			return nil, nil, fr.err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		readCtx, cancel := fr.frameTimeoutContext(ctx)
+		if readCtx.Err() != nil {
+			cancel()
+			return nil, nil, ErrIncompleteFrameTimeout
+		}
+		n, err := fr.read(readCtx)
+		cancel()
+		interrupted := err != nil && readCtx.Err() != nil
+		if interrupted {
+			// 被 ctx 或 FrameTimeout 打断产生的超时不是数据流本身的错误，不记录下来
+			err = nil
+		}
+		fr.err = err
+
+		if n > 0 {
+			// 先把数据交给 Frame，错误留到缓冲区中的帧取完后再返回
+			header, body, err := fr.decode(fr.scratch[:n], withHeader)
+			if err != nil {
+				return nil, nil, err
+			}
+			fr.track(body != nil)
+			if body != nil {
+				return header, body, nil
+			}
+		}
+
+		if interrupted {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, ErrIncompleteFrameTimeout
+		}
+	}
+}
+
+// Handshake 按 HandshakeSpec 读出数据流开头的握手帧，只能在第一次 Next 之前调用一次
+// - 与握手帧一起读到的后续数据会交给普通帧的解码，不会丢失
+// - 数据流在握手帧之前结束时返回 io.EOF，在握手帧中间结束时返回 io.ErrUnexpectedEOF
+// - 握手帧不写入 Tap，也不触发 HeaderConfig 中的回调
+// - 读取失败之后数据流的位置已经无法确定，之后的 Handshake 和 Next 都返回同一个错误
+func (fr *FrameReader) Handshake() ([]byte, error) {
+	switch {
+	case fr.handshakeErr != nil:
+		return nil, fr.handshakeErr
+	case fr.HandshakeSpec == nil:
+		return nil, errors.New("no HandshakeSpec configured")
+	case fr.handshakeDone:
+		return nil, errors.New("handshake already read")
+	}
+	body, err := fr.handshake()
+	if err != nil {
+		fr.handshakeErr = err
+		return nil, err
+	}
+	fr.handshakeDone = true
+	return body, nil
+}
+
+func (fr *FrameReader) handshake() ([]byte, error) {
+	spec := fr.HandshakeSpec
+	// decode 输入新读到的数据，取出握手帧之后 rest 返回留在握手解码器中的后续数据
+	var decode func(raw []byte) ([]byte, error)
+	var rest func() []byte
+	switch {
+	case (spec.Length > 0) == (spec.Header != nil):
+		return nil, errors.New("HandshakeSpec requires exactly one of Length and Header")
+	case spec.Header != nil:
+		if err := spec.Header.Validate(); err != nil {
+			return nil, err
+		}
+		hs := newFrame(spec.Header)
+		decode, rest = hs.readFrame, hs.Snapshot
+	default:
+		hs, err := NewFixedFrame(spec.Length)
+		if err != nil {
+			return nil, err
+		}
+		decode, rest = hs.ReadFrame, func() []byte { return hs.buf }
+	}
+
+	buffered := 0
+	for {
+		n, err := fr.read(context.Background())
+		if n > 0 {
+			buffered += n
+			body, decodeErr := decode(fr.scratch[:n])
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+			if body != nil {
+				if fr.readsWhole() {
+					fr.whole = append(fr.whole, rest()...)
+				} else {
+					fr.frame.Restore(rest())
+				}
+				fr.err = err
+				return body, nil
+			}
+		}
+		if err == io.EOF && buffered > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readsWhole 判断配置是否表示没有长度字段，整个数据流就是一个帧
+func (fr *FrameReader) readsWhole() bool {
+	hc := fr.frame.Hc
+	return hc.LengthEncoding == LengthFixed && hc.LengthFieldLength == 0 && len(hc.Fields) == 0 && hc.LengthFunc == nil
+}
+
+// nextWhole 读取到 io.EOF，把整个数据流作为一个帧返回
+func (fr *FrameReader) nextWhole(ctx context.Context) ([]byte, error) {
+	if fr.wholeDone {
+		return nil, io.EOF
+	}
+
+	limit := fr.frame.MaxFrameLength()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		n, err := fr.read(ctx)
+		fr.whole = append(fr.whole, fr.scratch[:n]...)
+		if limit > 0 && len(fr.whole) > limit {
+			fr.frame.notify(nil, ErrFrameTooLarge)
+			return nil, ErrFrameTooLarge
+		}
+
+		if err == io.EOF {
+			body := fr.whole
+			if body == nil {
+				body = []byte{}
+			}
+			fr.whole, fr.wholeDone = nil, true
+			if fr.Tap != nil {
+				if _, err := fr.Tap.Write(body); err != nil {
+					fr.frame.notify(nil, err)
+					return nil, err
+				}
+			}
+			fr.frame.notify(body, nil)
+			return body, nil
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				// 被 ctx 打断，已经读到的数据保留下来
+				return nil, ctxErr
+			}
+			return nil, err
+		}
+	}
+}
+
+// decode 把 raw 交给 Frame 解码，withHeader 为 true 时同时返回帧头部的拷贝，配置了 Tap 时写入原始帧
+func (fr *FrameReader) decode(raw []byte, withHeader bool) (header, body []byte, err error) {
+	if !withHeader && fr.Tap == nil {
+		body, err = fr.frame.ReadFrame(raw)
+		return nil, body, err
+	}
+	return fr.frame.readFrameCopy(raw, withHeader, fr.Tap)
+}
+
+// track 在取出帧或者读到数据之后更新未完成帧的计时起点
+func (fr *FrameReader) track(completed bool) {
+	switch {
+	case fr.FrameTimeout <= 0:
+	case fr.frame.buffered() == 0:
+		fr.pendingSince = time.Time{}
+	case completed || fr.pendingSince.IsZero():
+		fr.pendingSince = time.Now()
+	}
+}
+
+// frameTimeoutContext 有未完成的帧时在 ctx 上叠加 FrameTimeout 的截止时间
+func (fr *FrameReader) frameTimeoutContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if fr.FrameTimeout <= 0 || fr.pendingSince.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, fr.pendingSince.Add(fr.FrameTimeout))
+}
+
+// read 从底层 Reader 读取一次，底层支持读超时时 ctx 结束会打断阻塞中的 Read
+func (fr *FrameReader) read(ctx context.Context) (int, error) {
+	dr, ok := fr.r.(deadlineReader)
+	if !ok || ctx.Done() == nil {
+		return fr.r.Read(fr.scratch)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	stop := context.AfterFunc(ctx, func() {
+		defer wg.Done()
+		_ = dr.SetReadDeadline(time.Unix(1, 0))
+	})
+
+	n, err := fr.r.Read(fr.scratch)
+
+	if stop() {
+		wg.Done()
+	}
+	// 等待可能正在执行的 AfterFunc，保证它不会覆盖下面清除的读超时
+	wg.Wait()
+	_ = dr.SetReadDeadline(time.Time{})
+
+	return n, err
+}
+
+// Frames 启动一个 goroutine 持续读取帧并发送到返回的数据 channel，便于用 range 消费
+// - 数据流正常结束（io.EOF）时关闭两个 channel，错误 channel 中没有值
+// - 遇到其他错误或 ctx 结束时，先把错误（ctx 结束时为 ctx.Err()）写入错误 channel 再关闭两个 channel
+// - ctx 结束后 goroutine 会退出，不会因为没人消费而泄漏；打断阻塞中的 Read 的限制见 NextCtx
+//
+// 调用 Frames 之后不要再直接调用 Next/NextCtx
+func (fr *FrameReader) Frames(ctx context.Context) (<-chan []byte, <-chan error) {
+	frames := make(chan []byte)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(frames)
+		defer close(errs)
+
+		for {
+			body, err := fr.NextCtx(ctx)
+			if err != nil {
+				if err != io.EOF {
+					errs <- err
+				}
+				return
+			}
+
+			select {
+			case frames <- body:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return frames, errs
+}
+
+// WriteTo 实现 io.WriterTo，把每个帧的 body（后面跟着 Separator）依次写入 w，直到数据流结束
+// 数据流在帧边界正常结束时返回 nil，返回值为写入 w 的字节数
+func (fr *FrameReader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		body, err := fr.Next()
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+
+		n, err := w.Write(body)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		if len(fr.Separator) > 0 {
+			n, err = w.Write(fr.Separator)
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+}
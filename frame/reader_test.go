@@ -0,0 +1,636 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// chunkReader 每次 Read 返回一个预设的数据块，用于模拟分包
+type chunkReader struct {
+	chunks [][]byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+// TestFrameReader_Next 测试从 io.Reader 中读取帧
+func TestFrameReader_Next(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	tests := []struct {
+		name           string
+		reader         io.Reader
+		expectedFrames []string
+		expectedError  error
+	}{
+		{
+			name:           "多个帧一次读到",
+			reader:         bytes.NewReader([]byte{0x00, 0x02, 'h', 'i', 0x00, 0x03, 'f', 'o', 'o'}),
+			expectedFrames: []string{"hi", "foo"},
+			expectedError:  io.EOF,
+		},
+		{
+			name: "帧被任意拆分",
+			reader: &chunkReader{chunks: [][]byte{
+				{0x00}, {0x02, 'h'}, {'i', 0x00, 0x03}, {'f', 'o'}, {'o'},
+			}},
+			expectedFrames: []string{"hi", "foo"},
+			expectedError:  io.EOF,
+		},
+		{
+			name:           "逐字节读取",
+			reader:         iotest.OneByteReader(bytes.NewReader([]byte{0x00, 0x02, 'h', 'i'})),
+			expectedFrames: []string{"hi"},
+			expectedError:  io.EOF,
+		},
+		{
+			name:           "数据和EOF一起返回",
+			reader:         iotest.DataErrReader(bytes.NewReader([]byte{0x00, 0x02, 'h', 'i'})),
+			expectedFrames: []string{"hi"},
+			expectedError:  io.EOF,
+		},
+		{
+			name:          "空数据流",
+			reader:        bytes.NewReader(nil),
+			expectedError: io.EOF,
+		},
+		{
+			name:           "在帧中间结束",
+			reader:         bytes.NewReader([]byte{0x00, 0x02, 'h', 'i', 0x00, 0x03, 'f'}),
+			expectedFrames: []string{"hi"},
+			expectedError:  io.ErrUnexpectedEOF,
+		},
+		{
+			name:          "在头部中间结束",
+			reader:        bytes.NewReader([]byte{0x00}),
+			expectedError: io.ErrUnexpectedEOF,
+		},
+		{
+			name:          "底层Reader出错",
+			reader:        iotest.ErrReader(errors.New("boom")),
+			expectedError: errors.New("boom"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fr := NewReader(tt.reader, config)
+
+			var frames []string
+			var err error
+			for {
+				var body []byte
+				body, err = fr.Next()
+				if err != nil {
+					break
+				}
+				frames = append(frames, string(body))
+			}
+
+			if len(frames) != len(tt.expectedFrames) {
+				t.Fatalf("帧数量不匹配，期望: %v, 实际: %v", tt.expectedFrames, frames)
+			}
+			for i := range frames {
+				if frames[i] != tt.expectedFrames[i] {
+					t.Errorf("第 %d 个帧不匹配，期望: %q, 实际: %q", i+1, tt.expectedFrames[i], frames[i])
+				}
+			}
+			if err.Error() != tt.expectedError.Error() {
+				t.Errorf("错误不匹配，期望: %v, 实际: %v", tt.expectedError, err)
+			}
+
+			// 结束后再次调用应返回相同的错误
+			if _, again := fr.Next(); again == nil || again.Error() != err.Error() {
+				t.Errorf("重复调用应返回相同错误，实际: %v", again)
+			}
+		})
+	}
+}
+
+// TestFrameReader_StrictEOF 测试 StrictEOF 时数据流在帧中间结束返回剩余的字节数
+func TestFrameReader_StrictEOF(t *testing.T) {
+	config := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian}
+
+	fr := NewReader(bytes.NewReader([]byte{0x00, 0x02, 'h', 'i', 0x00, 0x03, 'f'}), config)
+	fr.StrictEOF = true
+	if body, err := fr.Next(); err != nil || string(body) != "hi" {
+		t.Fatalf("期望第一个帧为 hi，实际: %q, %v", body, err)
+	}
+	_, err := fr.Next()
+	var trailing *TrailingDataError
+	if !errors.As(err, &trailing) || trailing.Leftover != 3 {
+		t.Fatalf("期望剩余 3 字节的 *TrailingDataError，实际: %v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("*TrailingDataError 应包装 io.ErrUnexpectedEOF")
+	}
+
+	// 在帧边界结束时仍然返回 io.EOF
+	fr = NewReader(bytes.NewReader([]byte{0x00, 0x02, 'h', 'i'}), config)
+	fr.StrictEOF = true
+	fr.Next()
+	if _, err := fr.Next(); err != io.EOF {
+		t.Errorf("期望 io.EOF，实际: %v", err)
+	}
+}
+
+// TestFrameReader_Handshake 测试先读出格式不同的握手帧，再按普通配置读取后续帧
+func TestFrameReader_Handshake(t *testing.T) {
+	config := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian}
+	frames := []byte{0x00, 0x02, 'h', 'i', 0x00, 0x01, 'x'}
+
+	tests := []struct {
+		name string
+		spec *HandshakeSpec
+		data []byte
+		want string
+	}{
+		{"定长握手", &HandshakeSpec{Length: 4}, append([]byte("HELO"), frames...), "HELO"},
+		{"不同的头部配置", &HandshakeSpec{Header: &HeaderConfig{LengthFieldLength: 4, ByteOrder: binary.LittleEndian}},
+			append([]byte{3, 0, 0, 0, 'v', '1', '0'}, frames...), "v10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 握手帧和普通帧在同一次 Read 中到达，也分多次到达
+			for _, r := range []io.Reader{bytes.NewReader(tt.data), iotest.OneByteReader(bytes.NewReader(tt.data))} {
+				fr := NewReader(r, config)
+				fr.HandshakeSpec = tt.spec
+				if _, err := fr.Next(); err != ErrHandshakeNotRead {
+					t.Fatalf("握手之前调用 Next 期望 ErrHandshakeNotRead，实际: %v", err)
+				}
+				hello, err := fr.Handshake()
+				if err != nil || string(hello) != tt.want {
+					t.Fatalf("期望握手帧 %q，实际: %q, %v", tt.want, hello, err)
+				}
+				if _, err := fr.Handshake(); err == nil {
+					t.Error("重复调用 Handshake 应返回错误")
+				}
+				var got []string
+				for {
+					body, err := fr.Next()
+					if err != nil {
+						if err != io.EOF {
+							t.Fatalf("期望 io.EOF，实际: %v", err)
+						}
+						break
+					}
+					got = append(got, string(body))
+				}
+				if len(got) != 2 || got[0] != "hi" || got[1] != "x" {
+					t.Errorf("期望 [hi x]，实际: %q", got)
+				}
+			}
+		})
+	}
+
+	fr := NewReader(bytes.NewReader([]byte("HE")), config)
+	fr.HandshakeSpec = &HandshakeSpec{Length: 4}
+	if _, err := fr.Handshake(); err != io.ErrUnexpectedEOF {
+		t.Errorf("握手帧中间结束期望 io.ErrUnexpectedEOF，实际: %v", err)
+	}
+	if _, err := fr.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("握手失败之后 Next 应返回同一个错误，实际: %v", err)
+	}
+
+	fr = NewReader(bytes.NewReader(nil), config)
+	fr.HandshakeSpec = &HandshakeSpec{Length: 4, Header: config}
+	if _, err := fr.Handshake(); err == nil {
+		t.Error("同时设置 Length 和 Header 应返回错误")
+	}
+}
+
+// TestFrameReader_NextCtx 测试 ctx 取消和超时
+func TestFrameReader_NextCtx(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	t.Run("取消打断阻塞的Read", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+
+		fr := NewReader(server, config)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := fr.NextCtx(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("期望 context.Canceled，实际: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("取消后没有及时返回，耗时: %v", elapsed)
+		}
+
+		// 取消之后读超时应被清除，可以继续读取
+		go func() { _, _ = client.Write([]byte{0x00, 0x02, 'h', 'i'}) }()
+		body, err := fr.Next()
+		if err != nil || string(body) != "hi" {
+			t.Errorf("期望读到 hi，实际: %q, %v", body, err)
+		}
+	})
+
+	t.Run("超时", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+
+		fr := NewReader(server, config)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		// 只发送半个帧
+		go func() { _, _ = client.Write([]byte{0x00, 0x02, 'h'}) }()
+		_, err := fr.NextCtx(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("期望 context.DeadlineExceeded，实际: %v", err)
+		}
+
+		// 已缓冲的半个帧不会丢失
+		go func() { _, _ = client.Write([]byte{'i'}) }()
+		body, err := fr.Next()
+		if err != nil || string(body) != "hi" {
+			t.Errorf("期望读到 hi，实际: %q, %v", body, err)
+		}
+	})
+
+	t.Run("已取消的ctx", func(t *testing.T) {
+		fr := NewReader(bytes.NewReader([]byte{0x00, 0x02, 'h', 'i'}), config)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := fr.NextCtx(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("期望 context.Canceled，实际: %v", err)
+		}
+	})
+}
+
+// TestFrameReader_Frames 测试基于 channel 的读取
+func TestFrameReader_Frames(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	t.Run("正常结束", func(t *testing.T) {
+		fr := NewReader(bytes.NewReader([]byte{0x00, 0x01, 'a', 0x00, 0x01, 'b', 0x00, 0x01, 'c'}), config)
+		frames, errs := fr.Frames(context.Background())
+
+		var got string
+		for body := range frames {
+			got += string(body)
+		}
+		if got != "abc" {
+			t.Errorf("期望读到 abc，实际: %q", got)
+		}
+		if err := <-errs; err != nil {
+			t.Errorf("正常结束不应有错误，实际: %v", err)
+		}
+	})
+
+	t.Run("在帧中间结束", func(t *testing.T) {
+		fr := NewReader(bytes.NewReader([]byte{0x00, 0x01, 'a', 0x00}), config)
+		frames, errs := fr.Frames(context.Background())
+
+		count := 0
+		for range frames {
+			count++
+		}
+		if count != 1 {
+			t.Errorf("期望读到 1 个帧，实际: %d", count)
+		}
+		if err := <-errs; err != io.ErrUnexpectedEOF {
+			t.Errorf("期望 io.ErrUnexpectedEOF，实际: %v", err)
+		}
+	})
+
+	t.Run("取消时无人消费也不泄漏", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+
+		go func() {
+			for {
+				if _, err := client.Write([]byte{0x00, 0x01, 'a'}); err != nil {
+					return
+				}
+			}
+		}()
+
+		fr := NewReader(server, config)
+		ctx, cancel := context.WithCancel(context.Background())
+		frames, errs := fr.Frames(ctx)
+		<-frames // 消费一个后不再消费
+		cancel()
+
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("期望 context.Canceled，实际: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("取消后 goroutine 没有退出")
+		}
+		for range frames {
+		}
+	})
+}
+
+// TestFrameReader_FrameTimeout 测试未完成的帧超时
+func TestFrameReader_FrameTimeout(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	fr := NewReader(server, config)
+	fr.FrameTimeout = 50 * time.Millisecond
+
+	// 缓冲区为空时不计时，空闲超过 FrameTimeout 之后再发送完整帧不受影响
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = client.Write([]byte{0x00, 0x02, 'h', 'i'})
+	}()
+	body, err := fr.Next()
+	if err != nil || string(body) != "hi" {
+		t.Fatalf("期望读到 hi，实际: %q, %v", body, err)
+	}
+
+	// 只发送半个头部后停住
+	go func() { _, _ = client.Write([]byte{0x00}) }()
+	start := time.Now()
+	_, err = fr.Next()
+	if err != ErrIncompleteFrameTimeout {
+		t.Fatalf("期望 ErrIncompleteFrameTimeout，实际: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("超时后没有及时返回，耗时: %v", elapsed)
+	}
+}
+
+// TestFrameReader_WriteTo 测试把解码后的 body 写入普通 Writer
+func TestFrameReader_WriteTo(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+	data := []byte{0x00, 0x02, 'h', 'i', 0x00, 0x03, 'f', 'o', 'o'}
+
+	var out bytes.Buffer
+	var wt io.WriterTo = NewReader(bytes.NewReader(data), config)
+	n, err := wt.WriteTo(&out)
+	if err != nil || n != 5 || out.String() != "hifoo" {
+		t.Errorf("期望 hifoo，实际: %q, %d, %v", out.String(), n, err)
+	}
+
+	out.Reset()
+	fr := NewReader(bytes.NewReader(data), config)
+	fr.Separator = []byte("\n")
+	if n, err := fr.WriteTo(&out); err != nil || n != 7 || out.String() != "hi\nfoo\n" {
+		t.Errorf("期望带分隔符的输出，实际: %q, %d, %v", out.String(), n, err)
+	}
+
+	// 数据流在帧中间结束时返回错误
+	fr = NewReader(bytes.NewReader(data[:7]), config)
+	if _, err := fr.WriteTo(io.Discard); err != io.ErrUnexpectedEOF {
+		t.Errorf("期望 io.ErrUnexpectedEOF，实际: %v", err)
+	}
+}
+
+// TestFrameReader_NoLengthField 测试没有长度字段时读取到 EOF 作为一个帧
+func TestFrameReader_NoLengthField(t *testing.T) {
+	config := &HeaderConfig{}
+	fr := NewReader(&chunkReader{chunks: [][]byte{[]byte("hello "), []byte("world")}}, config)
+
+	body, err := fr.Next()
+	if err != nil || string(body) != "hello world" {
+		t.Fatalf("期望 hello world，实际: %q, %v", body, err)
+	}
+	if _, err := fr.Next(); err != io.EOF {
+		t.Errorf("唯一的帧返回后期望 io.EOF，实际: %v", err)
+	}
+
+	// 空数据流返回一个空帧
+	fr = NewReader(bytes.NewReader(nil), config)
+	if body, err := fr.Next(); err != nil || body == nil || len(body) != 0 {
+		t.Errorf("期望空帧，实际: %v, %v", body, err)
+	}
+
+	// 超过 MaxFrameLength
+	fr = NewReader(bytes.NewReader([]byte("hello world")), &HeaderConfig{MaxFrameLength: 5})
+	if _, err := fr.Next(); err != ErrFrameTooLarge {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+
+	// 其他读取错误原样返回
+	errRead := errors.New("read failed")
+	fr = NewReader(iotest.ErrReader(errRead), config)
+	if _, err := fr.Next(); err != errRead {
+		t.Errorf("期望读取错误，实际: %v", err)
+	}
+}
+
+// TestFrameReader_Tap 测试抓取原始帧并回放
+func TestFrameReader_Tap(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		Magic:             []byte{0xAB},
+		Resync:            true,
+	}
+	// 帧之间夹着垃圾字节，抓包中只包含完整的帧
+	data := []byte{0xAB, 0x00, 0x02, 'h', 'i', 0x01, 0x02, 0xAB, 0x00, 0x03, 'f', 'o', 'o'}
+
+	var capture bytes.Buffer
+	fr := NewReader(&chunkReader{chunks: [][]byte{data[:4], data[4:9], data[9:]}}, config)
+	fr.Tap = &capture
+	for _, want := range []string{"hi", "foo"} {
+		if body, err := fr.Next(); err != nil || string(body) != want {
+			t.Fatalf("期望 %q，实际: %q, %v", want, body, err)
+		}
+	}
+	expected := []byte{0xAB, 0x00, 0x02, 'h', 'i', 0xAB, 0x00, 0x03, 'f', 'o', 'o'}
+	if !bytesEqual(capture.Bytes(), expected) {
+		t.Fatalf("抓包内容不匹配，期望: %v, 实际: %v", expected, capture.Bytes())
+	}
+
+	// 回放
+	replay := NewReplayReader(bytes.NewReader(capture.Bytes()), config)
+	for _, want := range []string{"hi", "foo"} {
+		if body, err := replay.Next(); err != nil || string(body) != want {
+			t.Fatalf("回放期望 %q，实际: %q, %v", want, body, err)
+		}
+	}
+	if _, err := replay.Next(); err != io.EOF {
+		t.Errorf("期望 io.EOF，实际: %v", err)
+	}
+
+	// 回放时再抓一次包，得到的内容与原抓包相同
+	var again bytes.Buffer
+	replay = NewReplayReader(bytes.NewReader(capture.Bytes()), config)
+	replay.Tap = &again
+	for {
+		if _, err := replay.Next(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("期望 io.EOF，实际: %v", err)
+			}
+			break
+		}
+	}
+	if !bytesEqual(again.Bytes(), capture.Bytes()) {
+		t.Errorf("再次抓包内容不匹配，期望: %v, 实际: %v", capture.Bytes(), again.Bytes())
+	}
+
+	// 抓包在帧中间截断
+	replay = NewReplayReader(bytes.NewReader(capture.Bytes()[:8]), config)
+	if body, err := replay.Next(); err != nil || string(body) != "hi" {
+		t.Fatalf("期望 hi，实际: %q, %v", body, err)
+	}
+	var trailing *TrailingDataError
+	if _, err := replay.Next(); !errors.As(err, &trailing) || trailing.Leftover != 3 {
+		t.Errorf("期望剩余 3 字节的 TrailingDataError，实际: %v", err)
+	}
+}
+
+// TestFrameReader_ConcurrentWrite 测试同一个连接上一个 goroutine 读、另一个 goroutine 写
+func TestFrameReader_ConcurrentWrite(t *testing.T) {
+	// 两端共用同一个 HeaderConfig
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// 服务端把收到的帧原样回写
+	go func() {
+		fr, fw := NewReader(server, config), NewWriter(server, config)
+		for {
+			body, err := fr.Next()
+			if err != nil {
+				return
+			}
+			if _, err := fw.WriteFrame(body); err != nil {
+				return
+			}
+		}
+	}()
+
+	const count = 200
+	fr, fw := NewReader(client, config), NewWriter(client, config)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; i++ {
+			if _, err := fw.WriteFrame([]byte(fmt.Sprintf("msg-%d", i))); err != nil {
+				t.Errorf("写入第 %d 个帧失败: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < count; i++ {
+		body, err := fr.Next()
+		if err != nil {
+			t.Fatalf("读取第 %d 个帧失败: %v", i, err)
+		}
+		if want := fmt.Sprintf("msg-%d", i); string(body) != want {
+			t.Fatalf("第 %d 个帧期望 %s，实际: %s", i, want, body)
+		}
+	}
+	wg.Wait()
+}
+
+// countingReader 统计底层 Read 的调用次数
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.r.Read(p)
+}
+
+// TestNewReaderSize 测试读缓冲区大小
+func TestNewReaderSize(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	data := bytes.Repeat([]byte{0x00, 0x02, 'h', 'i'}, 100)
+
+	for _, tt := range []struct {
+		size, reads int
+	}{
+		{size: 4, reads: 101},
+		{size: 400, reads: 2},
+		{size: 0, reads: 2}, // 使用默认值
+	} {
+		cr := &countingReader{r: bytes.NewReader(data)}
+		fr := NewReaderSize(cr, config, tt.size)
+		frames := 0
+		for {
+			if _, err := fr.Next(); err != nil {
+				if err != io.EOF {
+					t.Fatalf("size %d 不期望出现错误: %v", tt.size, err)
+				}
+				break
+			}
+			frames++
+		}
+		if frames != 100 || cr.reads != tt.reads {
+			t.Errorf("size %d 期望 100 个帧、%d 次 Read，实际: %d 个帧、%d 次 Read", tt.size, tt.reads, frames, cr.reads)
+		}
+	}
+}
+
+// BenchmarkFrameReader_Size 比较不同读缓冲区大小下的吞吐
+func BenchmarkFrameReader_Size(b *testing.B) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+	data := bytes.Repeat([]byte{0x00, 0x04, 'p', 'i', 'n', 'g'}, 1000)
+
+	for _, size := range []int{16, 512, 4096} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			reads := 0
+			for i := 0; i < b.N; i++ {
+				cr := &countingReader{r: bytes.NewReader(data)}
+				fr := NewReaderSize(cr, config, size)
+				for {
+					if _, err := fr.Next(); err != nil {
+						break
+					}
+				}
+				reads += cr.reads
+			}
+			b.ReportMetric(float64(reads)/float64(b.N), "reads/op")
+			b.ReportMetric(float64(1000*b.N)/b.Elapsed().Seconds(), "frames/s")
+		})
+	}
+}
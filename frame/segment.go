@@ -0,0 +1,60 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// SegmentConfig 描述外层帧 body 内部的分段格式，用于每一列都单独带长度的列式协议：
+//
+//	[段数][段1长度][段2长度]...[段N长度][段1数据][段2数据]...[段N数据]
+type SegmentConfig struct {
+	ByteOrder                binary.ByteOrder
+	CountFieldLength         int // 段数字段占用字节数（2、4 或 8）
+	SegmentLengthFieldLength int // 每个段长度字段占用字节数（2、4 或 8）
+}
+
+// Split 把一个外层帧的 body 切分为各个段，返回的段与 body 共享内存
+// 段长度之和必须与剩余数据长度完全一致，否则返回错误
+func (sc *SegmentConfig) Split(body []byte) ([][]byte, error) {
+	countHc := &HeaderConfig{ByteOrder: sc.ByteOrder, LengthFieldLength: sc.CountFieldLength}
+	lengthHc := &HeaderConfig{ByteOrder: sc.ByteOrder, LengthFieldLength: sc.SegmentLengthFieldLength}
+
+	if sc.SegmentLengthFieldLength != 2 && sc.SegmentLengthFieldLength != 4 && sc.SegmentLengthFieldLength != 8 {
+		return nil, errors.New("unsupported LengthFieldLength, only 2, 4 or 8")
+	}
+
+	count, err := countHc.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	rest := body[sc.CountFieldLength:]
+
+	// 先校验所有长度字段都在 body 内，避免恶意的段数导致过大的分配
+	if count > len(rest)/sc.SegmentLengthFieldLength {
+		return nil, errors.New("segment count exceeds body")
+	}
+
+	lengths := make([]int, count)
+	for i := range lengths {
+		lengths[i], err = lengthHc.Parse(rest)
+		if err != nil {
+			return nil, err
+		}
+		rest = rest[sc.SegmentLengthFieldLength:]
+	}
+
+	segments := make([][]byte, count)
+	for i, n := range lengths {
+		if n > len(rest) {
+			return nil, errors.New("segment exceeds body")
+		}
+		segments[i] = rest[:n:n]
+		rest = rest[n:]
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after segments")
+	}
+
+	return segments, nil
+}
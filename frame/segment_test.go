@@ -0,0 +1,98 @@
+package frame
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestSegmentConfig_Split 测试把外层帧切分为多个段
+func TestSegmentConfig_Split(t *testing.T) {
+	config := &SegmentConfig{
+		ByteOrder:                binary.BigEndian,
+		CountFieldLength:         2,
+		SegmentLengthFieldLength: 2,
+	}
+
+	tests := []struct {
+		name          string
+		body          []byte
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:     "三个段",
+			body:     []byte{0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 'a', 'b', 'c'},
+			expected: []string{"a", "", "bc"},
+		},
+		{
+			name:     "零个段",
+			body:     []byte{0x00, 0x00},
+			expected: []string{},
+		},
+		{
+			name:          "没有段数字段",
+			body:          []byte{0x00},
+			expectedError: true,
+		},
+		{
+			name:          "段数超过body",
+			body:          []byte{0xFF, 0xFF, 0x00, 0x01},
+			expectedError: true,
+		},
+		{
+			name:          "段数据不足",
+			body:          []byte{0x00, 0x01, 0x00, 0x05, 'a'},
+			expectedError: true,
+		},
+		{
+			name:          "段之后还有多余数据",
+			body:          []byte{0x00, 0x01, 0x00, 0x01, 'a', 'b'},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, err := config.Split(tt.body)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("期望出现错误，但没有错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+			}
+			if len(segments) != len(tt.expected) {
+				t.Fatalf("段数量不匹配，期望: %d, 实际: %d", len(tt.expected), len(segments))
+			}
+			for i := range segments {
+				if string(segments[i]) != tt.expected[i] {
+					t.Errorf("第 %d 个段不匹配，期望: %q, 实际: %q", i+1, tt.expected[i], segments[i])
+				}
+			}
+		})
+	}
+
+	t.Run("不支持的段长度字段", func(t *testing.T) {
+		bad := &SegmentConfig{ByteOrder: binary.BigEndian, CountFieldLength: 2}
+		if _, err := bad.Split([]byte{0x00, 0x01, 0x00}); err == nil {
+			t.Errorf("期望出现错误，但没有错误")
+		}
+	})
+
+	t.Run("与外层帧配合使用", func(t *testing.T) {
+		frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 4}}
+		inner := []byte{0x00, 0x02, 0x00, 0x02, 0x00, 0x03, 'i', 'd', 'a', 'g', 'e'}
+		outer := append([]byte{0x00, 0x00, 0x00, byte(len(inner))}, inner...)
+
+		body, err := frame.ReadFrame(outer)
+		if err != nil {
+			t.Fatalf("读取外层帧失败: %v", err)
+		}
+		segments, err := config.Split(body)
+		if err != nil || len(segments) != 2 || string(segments[0]) != "id" || string(segments[1]) != "age" {
+			t.Errorf("切分结果不正确: %q, %v", segments, err)
+		}
+	})
+}
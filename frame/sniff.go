@@ -0,0 +1,46 @@
+package frame
+
+import "errors"
+
+// ErrNoCandidate SniffByteOrder 的所有候选配置都不能解析开头的数据
+var ErrNoCandidate = errors.New("no candidate HeaderConfig matches")
+
+// SniffByteOrder 在一个监听端口可能收到多种分帧协议时，用连接开头的数据判断对端使用的是哪一个配置
+// - 按顺序尝试 candidates，返回第一个能够合理解析 firstBytes 的配置：头部解析成功、长度在这个配置的 MaxFrameLength 之内，
+// 并且 firstBytes 已经包含完整的帧时，校验和、尾部等检查也都通过
+// - 排在前面的候选还无法判断（头部尚未收齐）时返回 (nil, nil)，调用方应读取更多数据后再次调用，不会越过它选择后面的候选
+// - 所有候选都解析失败时返回 ErrNoCandidate；候选配置本身不合法时返回 Validate 的错误
+//
+// 只检查连接开头的第一个帧，不修改 firstBytes，也不触发 HeaderConfig 中的回调。
+// 候选配置之间区分度越高（不同的 Magic、较小的 MaxFrameLength）越可靠，单凭长度字段可能有多个候选同时合理
+func SniffByteOrder(firstBytes []byte, candidates []*HeaderConfig) (*HeaderConfig, error) {
+	for _, hc := range candidates {
+		if hc == nil {
+			return nil, errors.New("nil HeaderConfig")
+		}
+		if err := hc.Validate(); err != nil {
+			return nil, err
+		}
+
+		// 试探时不应消耗令牌、丢弃帧或者按流式读取，用一份去掉这些行为的副本解析
+		probe := *hc
+		probe.RateLimiter = nil
+		probe.DropOversized = false
+		probe.StreamBody = false
+		probe.ZeroCopy = true
+		f := &Frame{Hc: &probe, buf: firstBytes[:len(firstBytes):len(firstBytes)], split: true}
+		body, err := f.readFrame(nil)
+		if err != nil {
+			continue
+		}
+		if body != nil {
+			return hc, nil
+		}
+		// 帧尚未完整，头部已经通过检查时同样认为这个候选合理
+		if _, _, ok, err := hc.parseHeader(firstBytes, hc.ByteOrder, hc.MaxFrameLength); err == nil && ok {
+			return hc, nil
+		}
+		return nil, nil
+	}
+	return nil, ErrNoCandidate
+}
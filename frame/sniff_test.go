@@ -0,0 +1,51 @@
+package frame
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestSniffByteOrder 测试按连接开头的数据在两种字节序的配置之间选择
+func TestSniffByteOrder(t *testing.T) {
+	big := &HeaderConfig{LengthFieldLength: 4, ByteOrder: binary.BigEndian, MaxFrameLength: 1024}
+	little := &HeaderConfig{LengthFieldLength: 4, ByteOrder: binary.LittleEndian, MaxFrameLength: 1024}
+	candidates := []*HeaderConfig{big, little}
+
+	tests := []struct {
+		name string
+		data []byte
+		want *HeaderConfig
+	}{
+		{"大端完整帧", []byte{0, 0, 0, 2, 'h', 'i'}, big},
+		{"小端完整帧", []byte{2, 0, 0, 0, 'h', 'i'}, little},
+		{"只有小端头部", []byte{0, 1, 0, 0}, little},
+		{"头部未收齐", []byte{0, 0}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SniffByteOrder(tt.data, candidates)
+			if err != nil || got != tt.want {
+				t.Errorf("期望 %p，实际: %p, %v", tt.want, got, err)
+			}
+		})
+	}
+
+	if _, err := SniffByteOrder([]byte{0xFF, 0, 0, 0xFF}, candidates); err != ErrNoCandidate {
+		t.Errorf("两种字节序都超过上限应返回 ErrNoCandidate，实际: %v", err)
+	}
+	if _, err := SniffByteOrder([]byte{0, 0, 0, 2}, []*HeaderConfig{{LengthFieldLength: 3}}); err == nil {
+		t.Error("不合法的候选配置应返回错误")
+	}
+}
+
+// TestSniffByteOrder_Checksum 测试完整帧到达时校验和也参与判断
+func TestSniffByteOrder_Checksum(t *testing.T) {
+	withSum := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, Magic: []byte{0xAB}, ChecksumLength: 1, ChecksumFunc: func(b []byte) uint32 { return 0x55 }}
+	other := &HeaderConfig{LengthFieldLength: 2, ByteOrder: binary.BigEndian, Magic: []byte{0xAB}}
+	data := []byte{0xAB, 0, 1, 'x', 0x00}
+
+	got, err := SniffByteOrder(data, []*HeaderConfig{withSum, other})
+	if err != nil || got != other {
+		t.Errorf("校验和不匹配时应选择第二个候选，实际: %p, %v", got, err)
+	}
+}
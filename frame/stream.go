@@ -0,0 +1,77 @@
+package frame
+
+import "errors"
+
+// ReadFrameChunk 在 StreamBody 模式下输入一次读到的数据，返回当前帧的 body 中新到达的一块，不必等整个帧收齐
+// - 头部解析出来之后，每次调用返回本次能取出的 body 数据，isLast 为 true 表示这是当前帧的最后一块
+// - 没有新的 body 数据时返回 (nil, false, nil)，等待下次补充
+// - body 为空的帧返回一个空的非 nil chunk，isLast 为 true
+// - 头部的错误与 ReadFrame 相同，OnHeader 在头部解析出来时触发，OnFrame 在最后一块返回时触发，参数为整个 body 的长度
+//
+// 已经返回的数据会立即从缓冲区移除，缓冲区最多保留一次输入的数据，内存占用与帧的大小无关。
+// chunk 的内存规则与 ReadFrame 相同（见 ZeroCopy / BufferPool）。一次调用最多返回一块，
+// 同一次输入中跟在当前帧之后的数据留在缓冲区中，继续调用（raw 传 nil）即可取出下一个帧
+func (f *Frame) ReadFrameChunk(raw []byte) (chunk []byte, isLast bool, err error) {
+	f.lock.Lock()
+	chunk, isLast, total, err := f.readFrameChunk(raw)
+	f.lock.Unlock()
+
+	f.notify(nil, err)
+	if isLast && f.Hc.OnFrame != nil {
+		f.Hc.OnFrame(total)
+	}
+	return chunk, isLast, err
+}
+
+// readFrameChunk 是 ReadFrameChunk 的实现，isLast 为 true 时 total 为整个 body 的长度，调用方需持有锁
+func (f *Frame) readFrameChunk(raw []byte) (chunk []byte, isLast bool, total int, err error) {
+	if !f.Hc.StreamBody {
+		return nil, false, 0, errors.New("ReadFrameChunk requires StreamBody")
+	}
+	if err := f.appendInput(raw); err != nil {
+		return nil, false, 0, err
+	}
+
+	if !f.streaming {
+		bodyLen, headerLen, ok, err := f.nextHeader()
+		if err != nil || !ok {
+			return nil, false, 0, err
+		}
+		f.recordHeader(bodyLen, headerLen)
+
+		// 头部不会返回给调用方，解析出来就可以丢掉
+		f.buf = f.buf[headerLen:]
+		f.consumed += uint64(headerLen)
+		f.streaming, f.streamLen, f.remaining = true, bodyLen, uint64(bodyLen)
+	}
+
+	n := len(f.buf)
+	if uint64(n) > f.remaining {
+		n = int(f.remaining)
+	}
+	if n == 0 && f.remaining > 0 {
+		return nil, false, 0, nil
+	}
+
+	chunk = f.buf[:n]
+	if !f.Hc.ZeroCopy {
+		chunk = f.Hc.getBuffer(n)
+		copy(chunk, f.buf[:n])
+	}
+
+	// 全部消费完时从头复用底层数组，缓冲区不会随着帧的大小增长
+	buf := f.buf
+	f.buf = f.buf[n:]
+	if len(f.buf) == 0 {
+		f.buf = buf[:0]
+	}
+	f.consumed += uint64(n)
+	f.remaining -= uint64(n)
+
+	if f.remaining > 0 {
+		return chunk, false, 0, nil
+	}
+	f.streaming = false
+	f.decoded++
+	return chunk, true, f.streamLen, nil
+}
@@ -0,0 +1,84 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestFrame_ReadFrameChunk 测试大帧的 body 分块返回且缓冲区不随帧大小增长
+func TestFrame_ReadFrameChunk(t *testing.T) {
+	var headers, frames []int
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 4,
+		StreamBody:        true,
+		OnHeader:          func(length int) { headers = append(headers, length) },
+		OnFrame:           func(size int) { frames = append(frames, size) },
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := bytes.Repeat([]byte("0123456789abcdef"), 1<<16) // 1MB
+	data, _ := config.Encode(body)
+	empty, _ := config.Encode(nil)
+	data = append(data, empty...)
+
+	frame := &Frame{Hc: config}
+	var got []byte
+	for len(data) > 0 {
+		n := min(len(data), 4096)
+		chunk, isLast, err := frame.ReadFrameChunk(data[:n])
+		data = data[n:]
+		if err != nil {
+			t.Fatalf("不期望出现错误，但出现了错误: %v", err)
+		}
+		got = append(got, chunk...)
+		if isLast {
+			break
+		}
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("分块拼接的结果与原始 body 不一致，长度 %d", len(got))
+	}
+	if cap(frame.buf) > 16*1024 {
+		t.Errorf("缓冲区不应随帧大小增长，实际容量: %d", cap(frame.buf))
+	}
+
+	// 空 body 的帧返回空的非 nil chunk
+	chunk, isLast, err := frame.ReadFrameChunk(data)
+	if err != nil || !isLast || chunk == nil || len(chunk) != 0 {
+		t.Errorf("空 body 应返回空 chunk 和 isLast，实际: %v, %v, %v", chunk, isLast, err)
+	}
+	if len(headers) != 2 || headers[0] != len(body) || len(frames) != 2 || frames[0] != len(body) || frames[1] != 0 {
+		t.Errorf("回调不正确: OnHeader %v, OnFrame %v", headers, frames)
+	}
+}
+
+// TestFrame_ReadFrameChunk_Errors 测试分块读取的使用限制
+func TestFrame_ReadFrameChunk_Errors(t *testing.T) {
+	frame := &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}}
+	if _, _, err := frame.ReadFrameChunk([]byte{0x00, 0x01, 'a'}); err == nil {
+		t.Error("未开启 StreamBody 时应返回错误")
+	}
+
+	frame = &Frame{Hc: &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, StreamBody: true}}
+	if chunk, isLast, err := frame.ReadFrameChunk([]byte{0x00, 0x03, 'a'}); string(chunk) != "a" || isLast || err != nil {
+		t.Fatalf("期望返回第一块 a，实际: %q, %v, %v", chunk, isLast, err)
+	}
+	if chunk, isLast, err := frame.ReadFrameChunk(nil); chunk != nil || isLast || err != nil {
+		t.Errorf("没有新数据时应返回 (nil, false, nil)，实际: %v, %v, %v", chunk, isLast, err)
+	}
+	if _, err := frame.ReadFrame([]byte("bc")); err == nil {
+		t.Error("分块返回期间调用 ReadFrame 应返回错误")
+	}
+	if chunk, isLast, err := frame.ReadFrameChunk(nil); string(chunk) != "bc" || !isLast || err != nil {
+		t.Errorf("ReadFrame 的输入应保留给 ReadFrameChunk，实际: %q, %v, %v", chunk, isLast, err)
+	}
+
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2, StreamBody: true, TrailerLength: 1}
+	if err := config.Validate(); err == nil {
+		t.Error("StreamBody 与 TrailerLength 同时使用应校验失败")
+	}
+}
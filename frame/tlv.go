@@ -0,0 +1,56 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// TLVReader 解析 [类型][长度][值] 形式的 TLV 帧，是按 Fields 配置 HeaderConfig 的便捷封装
+type TLVReader struct {
+	frame *Frame
+	typ   FieldSpec
+	err   error // NewTLV 的参数不合法时的错误，由 ReadTLV 返回
+}
+
+// NewTLV 创建一个 TLVReader，typeLen 和 lengthLen 分别为类型字段和长度字段的字节数（1、2 或 4），
+// 长度字段的值为 value 的字节数。参数不合法时 ReadTLV 返回对应的错误
+func NewTLV(typeLen, lengthLen int, order binary.ByteOrder) *TLVReader {
+	t := &TLVReader{typ: FieldSpec{Name: "type", Offset: 0, Width: typeLen}}
+	hc := &HeaderConfig{
+		ByteOrder: order,
+		Fields: []FieldSpec{
+			t.typ,
+			{Name: "length", Offset: typeLen, Width: lengthLen, Length: true},
+		},
+	}
+	for _, width := range []int{typeLen, lengthLen} {
+		if width != 1 && width != 2 && width != 4 {
+			t.err = errors.New("unsupported TLV field width, only 1, 2 or 4")
+		}
+	}
+	if t.err == nil {
+		t.err = hc.Validate()
+	}
+	t.frame = newFrame(hc)
+	return t
+}
+
+// ReadTLV 与 Frame.ReadFrame 相同，输入一次读到的数据，返回下一个完整 TLV 的类型和值
+// - 数据不足时返回 (0, nil, nil)，等待下次补充
+// - 长度为 0 的 TLV 返回非 nil 的空 value
+func (t *TLVReader) ReadTLV(raw []byte) (typ uint64, value []byte, err error) {
+	if t.err != nil {
+		return 0, nil, t.err
+	}
+
+	f := t.frame
+	f.lock.Lock()
+	rf, value, err := f.readFrameRaw(raw)
+	if value != nil {
+		typ = t.typ.value(rf.header, f.byteOrder())
+	}
+	f.lock.Unlock()
+
+	f.notify(value, err)
+	return typ, value, err
+}
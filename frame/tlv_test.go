@@ -0,0 +1,40 @@
+package frame
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestTLVReader 测试不同宽度的类型和长度字段
+func TestTLVReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		typeLen   int
+		lengthLen int
+		data      []byte
+	}{
+		{"1字节类型2字节长度", 1, 2, []byte{0x07, 0x00, 0x02, 'h', 'i'}},
+		{"2字节类型1字节长度", 2, 1, []byte{0x00, 0x07, 0x02, 'h', 'i'}},
+		{"4字节类型4字节长度", 4, 4, []byte{0, 0, 0, 0x07, 0, 0, 0, 0x02, 'h', 'i'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewTLV(tt.typeLen, tt.lengthLen, binary.BigEndian)
+			// 分两次到达
+			if typ, value, err := r.ReadTLV(tt.data[:3]); typ != 0 || value != nil || err != nil {
+				t.Fatalf("数据不足应返回 (0, nil, nil)，实际: %d, %v, %v", typ, value, err)
+			}
+			typ, value, err := r.ReadTLV(tt.data[3:])
+			if err != nil || typ != 7 || string(value) != "hi" {
+				t.Errorf("期望类型 7 和 hi，实际: %d, %q, %v", typ, value, err)
+			}
+		})
+	}
+
+	if _, _, err := NewTLV(3, 2, binary.BigEndian).ReadTLV([]byte{0x00}); err == nil {
+		t.Error("不支持的字段宽度应返回错误")
+	}
+	if _, _, err := NewTLV(1, 2, nil).ReadTLV([]byte{0x00}); err == nil {
+		t.Error("多字节字段缺少 ByteOrder 应返回错误")
+	}
+}
@@ -0,0 +1,76 @@
+package frame
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// FrameWriter 为每个 body 加上长度头部后写入底层 io.Writer，可以被多个 goroutine 同时使用
+type FrameWriter struct {
+	// ByteOrder 非 nil 时代替 HeaderConfig.ByteOrder 编码长度字段和校验和，只影响这个 FrameWriter，不修改共用的 HeaderConfig，
+	// 见 HeaderConfig.EncodeWithOrder。需要在第一次 WriteFrame 之前设置
+	ByteOrder binary.ByteOrder
+
+	w    io.Writer
+	hc   *HeaderConfig
+	size int    // 缓冲区大小，0 表示不缓冲
+	buf  []byte // 尚未 Flush 的已编码数据
+	lock sync.Mutex
+}
+
+// NewWriter 创建一个不带缓冲的 FrameWriter，每次 WriteFrame 都会立即写入 w
+func NewWriter(w io.Writer, hc *HeaderConfig) *FrameWriter {
+	return &FrameWriter{w: w, hc: hc}
+}
+
+// NewBufferedWriter 创建一个带缓冲的 FrameWriter，已编码的帧累积超过 size 字节时才写入 w，
+// 调用方需要在合适的时机调用 Flush
+func NewBufferedWriter(w io.Writer, hc *HeaderConfig, size int) *FrameWriter {
+	return &FrameWriter{w: w, hc: hc, size: size, buf: make([]byte, 0, size)}
+}
+
+// WriteFrame 编码 body 并写入底层 Writer，返回写入的 body 字节数
+// - 头部和 body 在一次 Write 中写出，不会在网络上被拆开
+// - body 超过 MaxFrameLength 或小于 MinFrameLength 时返回 ErrFrameTooLarge / ErrFrameTooSmall，不写入任何数据
+func (fw *FrameWriter) WriteFrame(body []byte) (int, error) {
+	frame, err := fw.hc.EncodeWithOrder(body, fw.ByteOrder)
+	if err != nil {
+		return 0, err
+	}
+
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+
+	if fw.size == 0 {
+		if _, err := fw.w.Write(frame); err != nil {
+			return 0, err
+		}
+		return len(body), nil
+	}
+
+	fw.buf = append(fw.buf, frame...)
+	if len(fw.buf) >= fw.size {
+		if err := fw.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(body), nil
+}
+
+// Flush 把缓冲区中的数据写入底层 Writer，不带缓冲的 FrameWriter 调用时什么也不做
+func (fw *FrameWriter) Flush() error {
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+	return fw.flush()
+}
+
+func (fw *FrameWriter) flush() error {
+	if len(fw.buf) == 0 {
+		return nil
+	}
+	n, err := fw.w.Write(fw.buf)
+	// 写入失败时保留没写出去的部分，便于调用方重试
+	fw.buf = fw.buf[:copy(fw.buf, fw.buf[n:])]
+	return err
+}
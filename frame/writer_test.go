@@ -0,0 +1,117 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// countingWriter 记录 Write 的调用次数
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+// TestFrameWriter_WriteFrame 测试写入的数据可以被 FrameReader 读回
+func TestFrameWriter_WriteFrame(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+		MaxFrameLength:    8,
+	}
+
+	var out countingWriter
+	fw := NewWriter(&out, config)
+	bodies := []string{"hello", "", "world"}
+	for _, body := range bodies {
+		n, err := fw.WriteFrame([]byte(body))
+		if err != nil {
+			t.Fatalf("写入 %q 时出现错误: %v", body, err)
+		}
+		if n != len(body) {
+			t.Errorf("返回的字节数不匹配，期望: %d, 实际: %d", len(body), n)
+		}
+	}
+	if out.writes != len(bodies) {
+		t.Errorf("每个帧应只调用一次 Write，实际调用 %d 次", out.writes)
+	}
+
+	if _, err := fw.WriteFrame([]byte("too long body")); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("期望 ErrFrameTooLarge，实际: %v", err)
+	}
+	if out.writes != len(bodies) {
+		t.Errorf("超长的帧不应写入任何数据")
+	}
+
+	fr := NewReader(&out.Buffer, config)
+	for _, body := range bodies {
+		got, err := fr.Next()
+		if err != nil || string(got) != body {
+			t.Errorf("期望读到 %q，实际: %q, %v", body, got, err)
+		}
+	}
+}
+
+// TestFrameWriter_Buffered 测试带缓冲的写入
+func TestFrameWriter_Buffered(t *testing.T) {
+	config := &HeaderConfig{
+		ByteOrder:         binary.BigEndian,
+		LengthFieldLength: 2,
+	}
+
+	var out countingWriter
+	fw := NewBufferedWriter(&out, config, 16)
+
+	// 每个帧 7 字节，第三个帧写入后超过 16 字节才触发写出
+	for i := 0; i < 2; i++ {
+		if _, err := fw.WriteFrame([]byte("hello")); err != nil {
+			t.Fatalf("写入时出现错误: %v", err)
+		}
+	}
+	if out.writes != 0 {
+		t.Errorf("缓冲区未满时不应写出，实际调用 %d 次", out.writes)
+	}
+	_, _ = fw.WriteFrame([]byte("hello"))
+	if out.writes != 1 || out.Len() != 21 {
+		t.Errorf("缓冲区满后应一次写出，调用 %d 次，长度 %d", out.writes, out.Len())
+	}
+
+	_, _ = fw.WriteFrame([]byte("x"))
+	if err := fw.Flush(); err != nil {
+		t.Fatalf("Flush 时出现错误: %v", err)
+	}
+	if out.writes != 2 || out.Len() != 24 {
+		t.Errorf("Flush 后数据不完整，调用 %d 次，长度 %d", out.writes, out.Len())
+	}
+	if err := fw.Flush(); err != nil || out.writes != 2 {
+		t.Errorf("缓冲区为空时 Flush 不应写出")
+	}
+}
+
+// TestFrameWriter_ByteOrder 测试代理按大端解码、按小端转发时共用同一个 HeaderConfig
+func TestFrameWriter_ByteOrder(t *testing.T) {
+	config := &HeaderConfig{ByteOrder: binary.BigEndian, LengthFieldLength: 2}
+
+	body, err := NewReader(bytes.NewReader([]byte{0x00, 0x02, 'h', 'i'}), config).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	fw := NewWriter(&out, config)
+	fw.ByteOrder = binary.LittleEndian
+	if _, err := fw.WriteFrame(body); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), []byte{0x02, 0x00, 'h', 'i'}) {
+		t.Errorf("期望按小端编码，实际: %x", out.Bytes())
+	}
+	if config.ByteOrder != binary.BigEndian {
+		t.Error("不应修改共用的 HeaderConfig")
+	}
+}
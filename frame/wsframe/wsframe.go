@@ -0,0 +1,209 @@
+// Package wsframe 解析和编码 WebSocket（RFC 6455）数据帧
+//
+// WebSocket 的长度字段宽度取决于第二个字节（7 位 / 126 后跟 2 字节 / 127 后跟 8 字节），掩码又让头部长度继续变化，
+// 无法用 frame.HeaderConfig 的定长头部描述，所以这里单独解析头部，用法与 frame.Frame 相同：
+// 每次输入读到的数据，数据不足时返回 nil，等待下次补充
+package wsframe
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Opcode 帧的操作码（第一个字节的低 4 位）
+type Opcode byte
+
+const (
+	OpContinuation Opcode = 0x0
+	OpText         Opcode = 0x1
+	OpBinary       Opcode = 0x2
+	OpClose        Opcode = 0x8
+	OpPing         Opcode = 0x9
+	OpPong         Opcode = 0xA
+)
+
+// IsControl 判断是否为控制帧（Close / Ping / Pong）
+func (op Opcode) IsControl() bool {
+	return op&0x8 != 0
+}
+
+// DefaultMaxPayloadLength Reader.MaxPayloadLength 为 0 时使用的上限
+const DefaultMaxPayloadLength = 16 << 20
+
+const maxControlPayloadLength = 125
+
+var (
+	ErrPayloadTooLarge = errors.New("websocket payload too large")
+	ErrMaskRequired    = errors.New("websocket frame from client is not masked")
+	ErrReservedBits    = errors.New("websocket reserved bits set")
+	ErrBadControlFrame = errors.New("websocket control frame fragmented or payload longer than 125")
+)
+
+// Frame 一个 WebSocket 数据帧
+type Frame struct {
+	Fin     bool
+	Rsv     byte // RSV1-3，位于第一个字节的 bit 6..4，按原样保存
+	Opcode  Opcode
+	Masked  bool
+	MaskKey [4]byte
+	Payload []byte // 已去掉掩码的 payload
+}
+
+// Reader 从连接读到的数据中依次取出 WebSocket 帧
+type Reader struct {
+	// MaxPayloadLength payload 的最大长度，超过时返回 ErrPayloadTooLarge，0 表示 DefaultMaxPayloadLength
+	MaxPayloadLength int
+
+	// RequireMask 要求每个帧都带掩码（服务端读取客户端发来的帧时应该设置），未带掩码时返回 ErrMaskRequired
+	RequireMask bool
+
+	// AllowReservedBits 允许 RSV1-3 非 0（协商了扩展时使用），否则返回 ErrReservedBits
+	AllowReservedBits bool
+
+	buf []byte
+}
+
+// ReadFrame 输入一次从 conn 读到的数据，输出一个完整的帧
+// - 如果数据不足，返回 (nil, nil)，等待下次补充
+// - 如果有多个帧，调用方需要多次调用 ReadFrame（raw 为 nil）才能依次取出
+// - raw 总是被拷贝到内部缓冲区，返回的 Payload 也是新分配的，不引用 raw
+// - 返回错误时不消费任何数据，连接应当关闭（RFC 6455 要求协议错误时断开）
+func (r *Reader) ReadFrame(raw []byte) (*Frame, error) {
+	r.buf = append(r.buf, raw...)
+
+	fr, n, err := r.parse(r.buf)
+	if fr == nil || err != nil {
+		return nil, err
+	}
+	r.buf = r.buf[:copy(r.buf, r.buf[n:])]
+	return fr, nil
+}
+
+// Buffered 返回缓冲区中尚未组成完整帧的字节数
+func (r *Reader) Buffered() int {
+	return len(r.buf)
+}
+
+// Parse 从 data 的开头解析一个帧，返回帧和它占用的字节数；数据不足时返回 (nil, 0, nil)
+// 使用 Reader 的零值配置：不要求掩码、不允许 RSV 位、payload 上限为 DefaultMaxPayloadLength
+func Parse(data []byte) (*Frame, int, error) {
+	var r Reader
+	return r.parse(data)
+}
+
+func (r *Reader) parse(data []byte) (*Frame, int, error) {
+	if len(data) < 2 {
+		return nil, 0, nil
+	}
+	fr := &Frame{
+		Fin:    data[0]&0x80 != 0,
+		Rsv:    data[0] >> 4 & 0x7,
+		Opcode: Opcode(data[0] & 0x0F),
+		Masked: data[1]&0x80 != 0,
+	}
+	if fr.Rsv != 0 && !r.AllowReservedBits {
+		return nil, 0, ErrReservedBits
+	}
+	if !fr.Masked && r.RequireMask {
+		return nil, 0, ErrMaskRequired
+	}
+
+	length := uint64(data[1] & 0x7F)
+	headerLen := 2
+	switch length {
+	case 126:
+		headerLen += 2
+		if len(data) < headerLen {
+			return nil, 0, nil
+		}
+		length = uint64(binary.BigEndian.Uint16(data[2:]))
+	case 127:
+		headerLen += 8
+		if len(data) < headerLen {
+			return nil, 0, nil
+		}
+		length = binary.BigEndian.Uint64(data[2:])
+		if length > math.MaxInt64 {
+			return nil, 0, errors.New("websocket payload length has the most significant bit set")
+		}
+	}
+	if fr.Opcode.IsControl() && (!fr.Fin || length > maxControlPayloadLength) {
+		return nil, 0, ErrBadControlFrame
+	}
+	if length > uint64(r.maxPayloadLength()) {
+		return nil, 0, ErrPayloadTooLarge
+	}
+
+	if fr.Masked {
+		if len(data) < headerLen+4 {
+			return nil, 0, nil
+		}
+		copy(fr.MaskKey[:], data[headerLen:])
+		headerLen += 4
+	}
+	total := headerLen + int(length)
+	if len(data) < total {
+		return nil, 0, nil
+	}
+
+	fr.Payload = make([]byte, length)
+	copy(fr.Payload, data[headerLen:total])
+	if fr.Masked {
+		mask(fr.Payload, fr.MaskKey)
+	}
+	return fr, total, nil
+}
+
+func (r *Reader) maxPayloadLength() int {
+	if r.MaxPayloadLength > 0 {
+		return r.MaxPayloadLength
+	}
+	return DefaultMaxPayloadLength
+}
+
+// AppendFrame 把 fr 编码后追加到 dst，返回追加后的切片
+// fr.Masked 为 true 时按 fr.MaskKey 对 payload 加掩码（客户端发出的帧），fr.Payload 本身不会被修改
+func AppendFrame(dst []byte, fr *Frame) ([]byte, error) {
+	if fr.Opcode > 0x0F || fr.Rsv > 0x7 {
+		return dst, errors.New("websocket opcode or reserved bits out of range")
+	}
+	if fr.Opcode.IsControl() && (!fr.Fin || len(fr.Payload) > maxControlPayloadLength) {
+		return dst, ErrBadControlFrame
+	}
+
+	b0 := byte(fr.Opcode) | fr.Rsv<<4
+	if fr.Fin {
+		b0 |= 0x80
+	}
+	var b1 byte
+	if fr.Masked {
+		b1 = 0x80
+	}
+	switch n := len(fr.Payload); {
+	case n <= 125:
+		dst = append(dst, b0, b1|byte(n))
+	case n <= 0xFFFF:
+		dst = append(dst, b0, b1|126)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, b0, b1|127)
+		dst = binary.BigEndian.AppendUint64(dst, uint64(n))
+	}
+
+	if !fr.Masked {
+		return append(dst, fr.Payload...), nil
+	}
+	dst = append(dst, fr.MaskKey[:]...)
+	start := len(dst)
+	dst = append(dst, fr.Payload...)
+	mask(dst[start:], fr.MaskKey)
+	return dst, nil
+}
+
+// mask 原地对 b 加 / 去掩码（两者是同一个异或运算）
+func mask(b []byte, key [4]byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
@@ -0,0 +1,90 @@
+package wsframe
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestReadFrameRFCExamples 使用 RFC 6455 5.7 节的示例
+func TestReadFrameRFCExamples(t *testing.T) {
+	masked := []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+	unmasked := []byte{0x81, 0x05, 0x48, 0x65, 0x6c, 0x6c, 0x6f}
+
+	r := &Reader{RequireMask: true}
+	// 逐字节到达
+	var fr *Frame
+	for i := range masked {
+		var err error
+		fr, err = r.ReadFrame(masked[i : i+1])
+		if err != nil {
+			t.Fatalf("第 %d 字节返回错误: %v", i, err)
+		}
+		if fr != nil && i != len(masked)-1 {
+			t.Fatalf("第 %d 字节就返回了帧", i)
+		}
+	}
+	if fr == nil || !fr.Fin || fr.Opcode != OpText || !fr.Masked || string(fr.Payload) != "Hello" {
+		t.Fatalf("期望带掩码的 Hello 文本帧，实际: %+v", fr)
+	}
+	if r.Buffered() != 0 {
+		t.Errorf("期望缓冲区为空，实际剩余 %d 字节", r.Buffered())
+	}
+
+	if _, err := r.ReadFrame(unmasked); err != ErrMaskRequired {
+		t.Errorf("RequireMask 时未带掩码应返回 ErrMaskRequired，实际: %v", err)
+	}
+
+	fr, n, err := Parse(unmasked)
+	if err != nil || n != len(unmasked) || fr.Masked || string(fr.Payload) != "Hello" {
+		t.Errorf("期望不带掩码的 Hello，实际: %+v, %d, %v", fr, n, err)
+	}
+}
+
+// TestExtendedLength 测试 16 位和 64 位扩展长度
+func TestExtendedLength(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		payload := bytes.Repeat([]byte{'x'}, size)
+		in := &Frame{Fin: true, Opcode: OpBinary, Masked: true, MaskKey: [4]byte{1, 2, 3, 4}, Payload: payload}
+		data, err := AppendFrame(nil, in)
+		if err != nil {
+			t.Fatalf("size %d: AppendFrame: %v", size, err)
+		}
+		if !bytes.Equal(in.Payload, payload) {
+			t.Fatalf("size %d: AppendFrame 修改了 Payload", size)
+		}
+
+		// 头部分两次到达
+		var r Reader
+		if fr, err := r.ReadFrame(data[:3]); fr != nil || err != nil {
+			t.Fatalf("size %d: 数据不足应返回 (nil, nil)，实际: %v, %v", size, fr, err)
+		}
+		fr, err := r.ReadFrame(data[3:])
+		if err != nil || fr == nil || fr.Opcode != OpBinary || !bytes.Equal(fr.Payload, payload) {
+			t.Fatalf("size %d: 期望原样取回 payload，实际: %v", size, err)
+		}
+	}
+}
+
+// TestProtocolErrors 测试超长 payload、RSV 位和非法控制帧
+func TestProtocolErrors(t *testing.T) {
+	r := &Reader{MaxPayloadLength: 100}
+	if _, err := r.ReadFrame([]byte{0x82, 0x7E, 0x01, 0x00}); err != ErrPayloadTooLarge {
+		t.Errorf("期望 ErrPayloadTooLarge，实际: %v", err)
+	}
+	if _, _, err := Parse([]byte{0xC1, 0x00}); err != ErrReservedBits {
+		t.Errorf("期望 ErrReservedBits，实际: %v", err)
+	}
+	if fr, _, err := (&Reader{AllowReservedBits: true}).parse([]byte{0xC1, 0x00}); err != nil || fr.Rsv != 4 {
+		t.Errorf("AllowReservedBits 时应保留 RSV1，实际: %+v, %v", fr, err)
+	}
+	// 分片的 Ping
+	if _, _, err := Parse([]byte{0x09, 0x00}); err != ErrBadControlFrame {
+		t.Errorf("期望 ErrBadControlFrame，实际: %v", err)
+	}
+	if _, _, err := Parse([]byte{0x82, 0x7F, 0x80, 0, 0, 0, 0, 0, 0, 0}); err == nil {
+		t.Error("64 位长度最高位为 1 应返回错误")
+	}
+	if _, err := AppendFrame(nil, &Frame{Fin: true, Opcode: OpPing, Payload: make([]byte, 126)}); err != ErrBadControlFrame {
+		t.Errorf("期望 ErrBadControlFrame，实际: %v", err)
+	}
+}